@@ -0,0 +1,315 @@
+// Package admin provides a JSON http.Handler to inspect and operate a taskmanager.Scheduler, which can be mounted
+// on any mux, next to the metrics handler:
+//
+//	mux.Handle("/metrics", promhttp.Handler())
+//	mux.Handle("/admin/", http.StripPrefix("/admin", admin.NewHandler(scheduler)))
+//
+// The Handler serves, relative to where it is mounted:
+//
+//	GET    /tasks                              TaskInfo of every Task
+//	GET    /tasks/{id}                         TaskInfo of a Task
+//	GET    /tasks/{id}/history                 RunRecords of a Task, newest first
+//	GET    /tasks/{id}/next?count=N            The next N run times of a Task (default 10)
+//	POST   /tasks/{id}/start                   Start, Stop, Pause or Resume a Task, returning its TaskInfo
+//	POST   /tasks/{id}/stop
+//	POST   /tasks/{id}/pause
+//	POST   /tasks/{id}/resume
+//	POST   /tasks/{id}/run?bypass=true         Run a Task now, optionally bypassing its Middleware
+//	POST   /tasks/{id}/runs/{instance}/cancel  Cancel a running Job instance
+//	GET    /tags                               Tags of every HasTagHandler added with AddTagHandler
+//	GET    /tags/{name}                        Tags of a HasTagHandler
+//	PUT    /tags/{name}/have/{tag}             Set or delete a tag a HasTagHandler has
+//	DELETE /tags/{name}/have/{tag}
+//
+// Errors are returned as {"error": "message"} with a matching status code.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/joberrors"
+	executionmiddleware "github.com/Fishwaldo/go-taskmanager/middleware/executation"
+)
+
+// maxForecast is the most run times GET /tasks/{id}/next returns
+const maxForecast = 1000
+
+//RunResponse The response to POST /tasks/{id}/run and POST /tasks/{id}/runs/{instance}/cancel
+type RunResponse struct {
+	ID       string
+	Instance string
+}
+
+//NextResponse The response to GET /tasks/{id}/next
+type NextResponse struct {
+	ID   string
+	Next []time.Time
+}
+
+//TagsResponse The tags of a HasTagHandler, the response to GET /tags/{name}
+type TagsResponse struct {
+	Name     string
+	Required []string
+	Have     []string
+}
+
+//ErrorResponse The response to a request that failed
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+//Handler A http.Handler serving the admin API of a Scheduler
+type Handler struct {
+	scheduler   *taskmanager.Scheduler
+	mx          sync.RWMutex
+	tagHandlers map[string]*executionmiddleware.HasTagHandler
+}
+
+//NewHandler Returns a Handler serving the admin API of s
+func NewHandler(s *taskmanager.Scheduler) *Handler {
+	return &Handler{
+		scheduler:   s,
+		tagHandlers: make(map[string]*executionmiddleware.HasTagHandler),
+	}
+}
+
+//AddTagHandler Make the tags of th available under /tags/{name}, replacing any HasTagHandler with the same name
+func (h *Handler) AddTagHandler(name string, th *executionmiddleware.HasTagHandler) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.tagHandlers[name] = th
+}
+
+//RemoveTagHandler Remove the HasTagHandler with the given name from /tags
+func (h *Handler) RemoveTagHandler(name string) {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	delete(h.tagHandlers, name)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts, err := splitPath(r.URL.EscapedPath())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	switch {
+	case len(parts) >= 1 && parts[0] == "tasks":
+		h.serveTasks(w, r, parts[1:])
+	case len(parts) >= 1 && parts[0] == "tags":
+		h.serveTags(w, r, parts[1:])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint %s", r.URL.Path))
+	}
+}
+
+// serveTasks serves /tasks, with parts the path after it
+func (h *Handler) serveTasks(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch len(parts) {
+	case 0:
+		if allow(w, r, http.MethodGet) {
+			writeJSON(w, http.StatusOK, h.scheduler.List())
+		}
+		return
+	case 1:
+		if allow(w, r, http.MethodGet) {
+			h.describe(w, parts[0], http.StatusOK)
+		}
+		return
+	}
+	id := parts[0]
+	switch action := parts[1]; {
+	case len(parts) == 2 && action == "history":
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		history, err := h.scheduler.History(id)
+		if err != nil {
+			writeSchedulerError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, history)
+	case len(parts) == 2 && action == "next":
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		count := 10
+		if c := r.URL.Query().Get("count"); c != "" {
+			var err error
+			if count, err = strconv.Atoi(c); err != nil || count < 1 || count > maxForecast {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("count must be between 1 and %d", maxForecast))
+				return
+			}
+		}
+		next, err := h.scheduler.Forecast(id, count)
+		if err != nil {
+			writeSchedulerError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, NextResponse{ID: id, Next: next})
+	case len(parts) == 2 && (action == "start" || action == "stop" || action == "pause" || action == "resume"):
+		if !allow(w, r, http.MethodPost) {
+			return
+		}
+		var err error
+		switch action {
+		case "start":
+			err = h.scheduler.Start(id)
+		case "stop":
+			err = h.scheduler.Stop(id)
+		case "pause":
+			err = h.scheduler.Pause(id)
+		case "resume":
+			err = h.scheduler.Resume(id)
+		}
+		if err != nil {
+			writeSchedulerError(w, err)
+			return
+		}
+		h.describe(w, id, http.StatusOK)
+	case len(parts) == 2 && action == "run":
+		if !allow(w, r, http.MethodPost) {
+			return
+		}
+		var opts []taskmanager.RunOption
+		if bypass, _ := strconv.ParseBool(r.URL.Query().Get("bypass")); bypass {
+			opts = append(opts, taskmanager.WithRunBypassMiddleware())
+		}
+		instance, err := h.scheduler.RunNow(id, opts...)
+		if err != nil {
+			writeSchedulerError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, RunResponse{ID: id, Instance: instance})
+	case len(parts) == 4 && action == "runs" && parts[3] == "cancel":
+		if !allow(w, r, http.MethodPost) {
+			return
+		}
+		if err := h.scheduler.CancelRun(id, parts[2]); err != nil {
+			writeSchedulerError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, RunResponse{ID: id, Instance: parts[2]})
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint %s", r.URL.Path))
+	}
+}
+
+func (h *Handler) describe(w http.ResponseWriter, id string, status int) {
+	info, err := h.scheduler.Describe(id)
+	if err != nil {
+		writeSchedulerError(w, err)
+		return
+	}
+	writeJSON(w, status, info)
+}
+
+// serveTags serves /tags, with parts the path after it
+func (h *Handler) serveTags(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 0 {
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+		h.mx.RLock()
+		tags := make([]TagsResponse, 0, len(h.tagHandlers))
+		for name, th := range h.tagHandlers {
+			tags = append(tags, TagsResponse{Name: name, Required: th.RequiredTags(), Have: th.HaveTags()})
+		}
+		h.mx.RUnlock()
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+		writeJSON(w, http.StatusOK, tags)
+		return
+	}
+	name := parts[0]
+	h.mx.RLock()
+	th, ok := h.tagHandlers[name]
+	h.mx.RUnlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("tag handler %s not found", name))
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		if !allow(w, r, http.MethodGet) {
+			return
+		}
+	case len(parts) == 3 && parts[1] == "have":
+		if !allow(w, r, http.MethodPut, http.MethodDelete) {
+			return
+		}
+		if r.Method == http.MethodPut {
+			th.SetHaveTags(parts[2])
+		} else {
+			th.DelHaveTags(parts[2])
+		}
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint %s", r.URL.Path))
+		return
+	}
+	writeJSON(w, http.StatusOK, TagsResponse{Name: name, Required: th.RequiredTags(), Have: th.HaveTags()})
+}
+
+// splitPath returns the unescaped segments of path, so IDs may contain escaped slashes
+func splitPath(path string) ([]string, error) {
+	var parts []string
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, unescaped)
+	}
+	return parts, nil
+}
+
+// allow returns true if the method of r is one of methods, otherwise it writes a 405 response
+func allow(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+// writeSchedulerError writes err, returned by the Scheduler, with a matching status code
+func writeSchedulerError(w http.ResponseWriter, err error) {
+	var (
+		notFound    joberrors.ErrorScheduleNotFound
+		runNotFound joberrors.ErrorRunNotFound
+		shutdown    joberrors.ErrorSchedulerShutdown
+	)
+	switch {
+	case errors.As(err, &notFound), errors.As(err, &runNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.As(err, &shutdown):
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
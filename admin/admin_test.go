@@ -0,0 +1,118 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	executionmiddleware "github.com/Fishwaldo/go-taskmanager/middleware/executation"
+	"github.com/go-logr/logr"
+)
+
+var testTime = time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+func TestHandler(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(fc))
+	defer s.Shutdown(context.Background())
+	runs := make(chan struct{}, 10)
+	timer, _ := taskmanager.NewFixed(1 * time.Hour)
+	if err := s.Add(context.Background(), "export/daily", timer, func(ctx context.Context) { runs <- struct{}{} }); err != nil {
+		t.Fatalf("Add Returned Error: %s", err.Error())
+	}
+	th := executionmiddleware.NewTagHandler()
+	th.SetRequiredTags("db")
+	h := NewHandler(s)
+	h.AddTagHandler("database", th)
+	srv := httptest.NewServer(http.StripPrefix("/admin", h))
+	defer srv.Close()
+
+	do := func(method, path string, status int, v interface{}) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+"/admin"+path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s Returned Error: %s", method, path, err.Error())
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Fatalf("%s %s Returned Status %d, not %d", method, path, resp.StatusCode, status)
+		}
+		if v != nil {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("%s %s Returned invalid JSON: %s", method, path, err.Error())
+			}
+		}
+	}
+
+	var list []taskmanager.TaskInfo
+	do(http.MethodGet, "/tasks", http.StatusOK, &list)
+	if len(list) != 1 || list[0].ID != "export/daily" || list[0].State != taskmanager.TaskState_Stopped {
+		t.Errorf("GET /tasks Returned %+v", list)
+	}
+	var info taskmanager.TaskInfo
+	do(http.MethodPost, "/tasks/export%2Fdaily/start", http.StatusOK, &info)
+	if info.State != taskmanager.TaskState_Scheduled || !info.NextRun.Equal(testTime.Add(1*time.Hour)) {
+		t.Errorf("POST start Returned %+v", info)
+	}
+	do(http.MethodPost, "/tasks/export%2Fdaily/pause", http.StatusOK, &info)
+	if info.State != taskmanager.TaskState_Paused {
+		t.Errorf("POST pause Returned State %s", info.State)
+	}
+	do(http.MethodPost, "/tasks/export%2Fdaily/resume", http.StatusOK, &info)
+	if info.State != taskmanager.TaskState_Scheduled {
+		t.Errorf("POST resume Returned State %s", info.State)
+	}
+
+	var next NextResponse
+	do(http.MethodGet, "/tasks/export%2Fdaily/next?count=3", http.StatusOK, &next)
+	if len(next.Next) != 3 || !next.Next[2].Equal(testTime.Add(3*time.Hour)) {
+		t.Errorf("GET next Returned %+v", next)
+	}
+
+	var run RunResponse
+	do(http.MethodPost, "/tasks/export%2Fdaily/run?bypass=true", http.StatusAccepted, &run)
+	if run.Instance == "" {
+		t.Errorf("POST run Returned %+v", run)
+	}
+	<-runs
+	deadline := time.Now().Add(5 * time.Second)
+	var history []taskmanager.RunRecord
+	for {
+		do(http.MethodGet, "/tasks/export%2Fdaily/history", http.StatusOK, &history)
+		if len(history) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the run to be recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if history[0].InstanceID != run.Instance || !history[0].OutOfBand {
+		t.Errorf("GET history Returned %+v", history)
+	}
+	do(http.MethodPost, "/tasks/export%2Fdaily/runs/"+run.Instance+"/cancel", http.StatusNotFound, nil)
+
+	var tags TagsResponse
+	do(http.MethodPut, "/tags/database/have/db", http.StatusOK, &tags)
+	if len(tags.Have) != 1 || !th.IsHaveTag("db") {
+		t.Errorf("PUT tag Returned %+v", tags)
+	}
+	do(http.MethodDelete, "/tags/database/have/db", http.StatusOK, &tags)
+	if len(tags.Have) != 0 || len(tags.Required) != 1 || th.IsHaveTag("db") {
+		t.Errorf("DELETE tag Returned %+v", tags)
+	}
+
+	var errResp ErrorResponse
+	do(http.MethodGet, "/tasks/missing", http.StatusNotFound, &errResp)
+	if errResp.Error == "" {
+		t.Errorf("GET of a missing Task did not return an error message")
+	}
+	do(http.MethodGet, "/tasks/export%2Fdaily/start", http.StatusMethodNotAllowed, nil)
+	do(http.MethodGet, "/tags/missing", http.StatusNotFound, nil)
+}
@@ -0,0 +1,30 @@
+package clock
+
+import (
+	"time"
+)
+
+//Clock is an Interface for the source of time used by the Scheduler, Tasks, Timers and Jobs.
+//Supplying a Clock other than the Real Clock (such as Fake) allows scheduling to be tested without waiting
+//for the wall clock.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+//New Returns a Clock backed by the time package
+func New() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,131 @@
+package clock
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+var _ Clock = (*Fake)(nil)
+
+type fakeWaiter struct {
+	until time.Time
+	seq   uint64
+	c     chan time.Time
+}
+
+//Fake is a Clock that only moves when told to via Advance or Set. Channels returned by After fire in the order
+//they become due, with Now() returning the time they were due when each one fires.
+type Fake struct {
+	mx      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	seq     uint64
+	waiters fakeWaiters
+}
+
+//NewFake Returns a Fake Clock set to now
+func NewFake(now time.Time) *Fake {
+	f := &Fake{
+		now: now,
+	}
+	f.cond = sync.NewCond(&f.mx)
+	return f
+}
+
+//Now Returns the current time of the Fake Clock
+func (f *Fake) Now() time.Time {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.now
+}
+
+//After Returns a channel that fires once the Fake Clock has been advanced by at least d
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	c := make(chan time.Time, 1)
+	if d <= 0 {
+		c <- f.now
+		return c
+	}
+	f.seq++
+	heap.Push(&f.waiters, &fakeWaiter{until: f.now.Add(d), seq: f.seq, c: c})
+	f.cond.Broadcast()
+	return c
+}
+
+//Advance moves the Fake Clock forward by d, firing every channel that becomes due in order.
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+//Set moves the Fake Clock to t, firing every channel that becomes due in order. Setting a time in
+//the past moves the clock backwards but does not fire anything.
+func (f *Fake) Set(t time.Time) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	for {
+		w := f.nextDue(t)
+		if w == nil {
+			break
+		}
+		f.now = w.until
+		w.c <- w.until
+	}
+	f.now = t
+}
+
+//Waiters Returns the number of channels waiting for the Fake Clock to advance
+func (f *Fake) Waiters() int {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return len(f.waiters)
+}
+
+//BlockUntil blocks until at least n channels are waiting for the Fake Clock to advance
+func (f *Fake) BlockUntil(n int) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// nextDue removes and returns the earliest waiter due at or before t. Must be called with f.mx held.
+func (f *Fake) nextDue(t time.Time) *fakeWaiter {
+	if len(f.waiters) == 0 || f.waiters[0].until.After(t) {
+		return nil
+	}
+	return heap.Pop(&f.waiters).(*fakeWaiter)
+}
+
+type fakeWaiters []*fakeWaiter
+
+func (w fakeWaiters) Len() int {
+	return len(w)
+}
+
+func (w fakeWaiters) Less(i, j int) bool {
+	if w[i].until.Equal(w[j].until) {
+		return w[i].seq < w[j].seq
+	}
+	return w[i].until.Before(w[j].until)
+}
+
+func (w fakeWaiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+}
+
+func (w *fakeWaiters) Push(x interface{}) {
+	*w = append(*w, x.(*fakeWaiter))
+}
+
+func (w *fakeWaiters) Pop() interface{} {
+	old := *w
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*w = old[:n-1]
+	return item
+}
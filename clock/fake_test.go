@@ -0,0 +1,50 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeAfterOrder(t *testing.T) {
+	start := time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC)
+	fc := NewFake(start)
+	c3 := fc.After(3 * time.Second)
+	c1 := fc.After(1 * time.Second)
+	c2 := fc.After(2 * time.Second)
+	if fc.Waiters() != 3 {
+		t.Errorf("Waiters != 3 - %d", fc.Waiters())
+	}
+
+	fc.Advance(2 * time.Second)
+	if got := <-c1; !got.Equal(start.Add(1 * time.Second)) {
+		t.Errorf("c1 fired at %s", got)
+	}
+	if got := <-c2; !got.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("c2 fired at %s", got)
+	}
+	select {
+	case <-c3:
+		t.Errorf("c3 fired early")
+	default:
+	}
+	if !fc.Now().Equal(start.Add(2 * time.Second)) {
+		t.Errorf("Now != start + 2s - %s", fc.Now())
+	}
+
+	fc.Advance(1 * time.Second)
+	if got := <-c3; !got.Equal(start.Add(3 * time.Second)) {
+		t.Errorf("c3 fired at %s", got)
+	}
+	if fc.Waiters() != 0 {
+		t.Errorf("Waiters != 0 - %d", fc.Waiters())
+	}
+}
+
+func TestFakeAfterImmediate(t *testing.T) {
+	fc := NewFake(time.Now())
+	select {
+	case <-fc.After(0):
+	default:
+		t.Errorf("After(0) did not fire immediately")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/admin"
+)
+
+// client calls the admin API of a Scheduler, see the admin package
+type client struct {
+	http *http.Client
+	base string
+}
+
+// apiError is a error response of the admin API
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e apiError) Error() string {
+	return e.message
+}
+
+// newClient returns a client of the admin API at addr, such as http://localhost:6060/admin. If socket is not empty,
+// requests are sent over the unix socket at that path instead, and only the path of addr is used.
+func newClient(addr string, socket string, timeout time.Duration) (*client, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	c := &client{http: &http.Client{Timeout: timeout}}
+	if socket != "" {
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		u.Scheme, u.Host = "http", "unix"
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid address %q: must be a http or https URL", addr)
+	}
+	c.base = strings.TrimSuffix(u.String(), "/")
+	return c, nil
+}
+
+// do sends a request to path, with every element of path escaped, and decodes the JSON response into v
+func (c *client) do(method string, query url.Values, v interface{}, path ...string) error {
+	for i, p := range path {
+		path[i] = url.PathEscape(p)
+	}
+	u := c.base + "/" + strings.Join(path, "/")
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var errResp admin.ErrorResponse
+		if json.Unmarshal(body, &errResp) != nil || errResp.Error == "" {
+			errResp.Error = resp.Status
+		}
+		return apiError{status: resp.StatusCode, message: errResp.Error}
+	}
+	if raw, ok := v.(*json.RawMessage); ok {
+		*raw = body
+		return nil
+	}
+	return json.Unmarshal(body, v)
+}
+
+// isNotFound returns true if err is a 404 response
+func isNotFound(err error) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound
+}
+
+// isAPIError returns true if err is a error response, rather than a failure to reach the admin API
+func isAPIError(err error) bool {
+	var apiErr apiError
+	return errors.As(err, &apiErr)
+}
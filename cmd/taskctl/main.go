@@ -0,0 +1,405 @@
+// Command taskctl operates a live Scheduler through its admin API, see the admin package.
+//
+//	taskctl [-addr URL] [-socket PATH] [-o table|json] <command> [arguments]
+//
+// The flags of a command, such as -count of next, can be given before or after its arguments.
+//
+// Exit codes are 0 on success, 1 if the admin API returned a error, 2 on a usage error, 3 if the Task or tag handler
+// was not found, and 4 if the admin API could not be reached.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/admin"
+)
+
+// Exit codes
+const (
+	exitOK          = 0
+	exitError       = 1
+	exitUsage       = 2
+	exitNotFound    = 3
+	exitUnavailable = 4
+)
+
+// usageError is a error in the command line
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// env is what a command runs with
+type env struct {
+	client *client
+	out    io.Writer
+	json   bool
+}
+
+// command is a taskctl command
+type command struct {
+	args  string
+	help  string
+	run   func(e *env, args []string) error
+	flags func(fs *flag.FlagSet) func(e *env, args []string) error
+}
+
+var commands = map[string]command{
+	"list":     {help: "List the Tasks and their next runs", run: list},
+	"describe": {args: "ID", help: "Describe a Task", run: describe},
+	"start":    {args: "ID", help: "Start a Task", run: action("start")},
+	"stop":     {args: "ID", help: "Stop a Task", run: action("stop")},
+	"pause":    {args: "ID", help: "Pause a Task", run: action("pause")},
+	"resume":   {args: "ID", help: "Resume a Task", run: action("resume")},
+	"run":      {args: "[-bypass] ID", help: "Run a Task now, -bypass skips its Middleware", flags: runFlags},
+	"cancel":   {args: "ID INSTANCE", help: "Cancel a running Job instance of a Task", run: cancel},
+	"history":  {args: "ID", help: "Show the run history of a Task", run: history},
+	"next":     {args: "[-count N] ID", help: "Show the next N run times of a Task", flags: nextFlags},
+	"tags":     {args: "[NAME] | set NAME TAG | del NAME TAG", help: "Show or change the tags of HasTagHandlers", run: tags},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs taskctl with args, returning the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("taskctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", envOr("TASKCTL_ADDR", "http://localhost:6060/admin"), "URL the admin API is mounted at, or $TASKCTL_ADDR")
+	socket := fs.String("socket", os.Getenv("TASKCTL_SOCKET"), "unix socket to connect to instead of the host of -addr, or $TASKCTL_SOCKET")
+	output := fs.String("o", "table", "output format, table or json")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of requests")
+	fs.Usage = func() { usage(fs) }
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "taskctl: invalid output format %q, must be table or json\n", *output)
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		usage(fs)
+		return exitUsage
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "taskctl: unknown command %q\n", fs.Arg(0))
+		usage(fs)
+		return exitUsage
+	}
+	c, err := newClient(*addr, *socket, *timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "taskctl: %s\n", err.Error())
+		return exitUsage
+	}
+
+	e := &env{client: c, out: stdout, json: *output == "json"}
+	cmdArgs := fs.Args()[1:]
+	runCmd := cmd.run
+	if cmd.flags != nil {
+		cfs := flag.NewFlagSet("taskctl "+fs.Arg(0), flag.ContinueOnError)
+		cfs.SetOutput(stderr)
+		runCmd = cmd.flags(cfs)
+		if cmdArgs, err = parseInterspersed(cfs, cmdArgs); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return exitOK
+			}
+			return exitUsage
+		}
+	}
+	err = runCmd(e, cmdArgs)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(stderr, "taskctl: %s\nusage: taskctl %s %s\n", err.Error(), fs.Arg(0), cmd.args)
+		return exitUsage
+	case isNotFound(err):
+		fmt.Fprintf(stderr, "taskctl: %s\n", err.Error())
+		return exitNotFound
+	case isAPIError(err):
+		fmt.Fprintf(stderr, "taskctl: %s\n", err.Error())
+		return exitError
+	default:
+		fmt.Fprintf(stderr, "taskctl: %s\n", err.Error())
+		return exitUnavailable
+	}
+}
+
+func usage(fs *flag.FlagSet) {
+	w := fs.Output()
+	fmt.Fprintf(w, "usage: taskctl [flags] <command> [arguments]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", name, commands[name].args, commands[name].help)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nFlags:\n")
+	fs.PrintDefaults()
+}
+
+// parseInterspersed parses the flags of fs wherever they are in args, such as after the ID in "next ID -count 5", as
+// the flag package stops at the first argument that is not a flag. Returns the other arguments, and every argument
+// after "--".
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		remaining := fs.Args()
+		if len(remaining) == 0 {
+			return rest, nil
+		}
+		if consumed := len(args) - len(remaining); consumed > 0 && args[consumed-1] == "--" {
+			return append(rest, remaining...), nil
+		}
+		rest = append(rest, remaining[0])
+		args = remaining[1:]
+	}
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// args returns a usageError unless there are exactly n args
+func args(a []string, n int) error {
+	if len(a) != n {
+		return usageError{message: fmt.Sprintf("expected %d arguments, got %d", n, len(a))}
+	}
+	return nil
+}
+
+// print writes v as JSON, or as a table written by table
+func (e *env) print(v interface{}, table func(w io.Writer)) error {
+	if e.json {
+		enc := json.NewEncoder(e.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+func list(e *env, a []string) error {
+	if err := args(a, 0); err != nil {
+		return err
+	}
+	var infos []taskmanager.TaskInfo
+	if err := e.client.do(http.MethodGet, nil, &infos, "tasks"); err != nil {
+		return err
+	}
+	return e.print(infos, func(w io.Writer) {
+		fmt.Fprintln(w, "ID\tSTATE\tNEXT RUN\tLAST OUTCOME\tRUNS\tFAILURES")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\n", info.ID, info.State, formatTime(info.NextRun), info.LastOutcome, info.Runs, info.Failures)
+		}
+	})
+}
+
+func describe(e *env, a []string) error {
+	if err := args(a, 1); err != nil {
+		return err
+	}
+	var info taskmanager.TaskInfo
+	if err := e.client.do(http.MethodGet, nil, &info, "tasks", a[0]); err != nil {
+		return err
+	}
+	return e.print(info, func(w io.Writer) { describeInfo(w, info) })
+}
+
+func describeInfo(w io.Writer, info taskmanager.TaskInfo) {
+	labels := make([]string, 0, len(info.Labels))
+	for k, v := range info.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	fmt.Fprintf(w, "ID:\t%s\n", info.ID)
+	fmt.Fprintf(w, "State:\t%s\n", info.State)
+	fmt.Fprintf(w, "Job Type:\t%s\n", dash(info.JobType))
+	fmt.Fprintf(w, "Next Run:\t%s\n", formatTime(info.NextRun))
+	fmt.Fprintf(w, "Last Start:\t%s\n", formatTime(info.LastStart))
+	fmt.Fprintf(w, "Last Finish:\t%s\n", formatTime(info.LastFinish))
+	fmt.Fprintf(w, "Last Outcome:\t%s\n", info.LastOutcome)
+	fmt.Fprintf(w, "Last Error:\t%s\n", dash(info.LastError))
+	fmt.Fprintf(w, "Runs:\t%d\n", info.Runs)
+	fmt.Fprintf(w, "Failures:\t%d\n", info.Failures)
+	fmt.Fprintf(w, "Retries:\t%d\n", info.Retries)
+	fmt.Fprintf(w, "Defers:\t%d\n", info.Defers)
+	fmt.Fprintf(w, "Active Instances:\t%s\n", dash(strings.Join(info.ActiveInstances, ", ")))
+	fmt.Fprintf(w, "Middlewares:\t%s\n", dash(strings.Join(info.Middlewares, ", ")))
+	fmt.Fprintf(w, "Labels:\t%s\n", dash(strings.Join(labels, ", ")))
+	fmt.Fprintf(w, "Priority:\t%d\n", info.Priority)
+}
+
+// action returns a command that POSTs name to a Task
+func action(name string) func(e *env, a []string) error {
+	return func(e *env, a []string) error {
+		if err := args(a, 1); err != nil {
+			return err
+		}
+		var info taskmanager.TaskInfo
+		if err := e.client.do(http.MethodPost, nil, &info, "tasks", a[0], name); err != nil {
+			return err
+		}
+		return e.print(info, func(w io.Writer) {
+			fmt.Fprintln(w, "ID\tSTATE\tNEXT RUN")
+			fmt.Fprintf(w, "%s\t%s\t%s\n", info.ID, info.State, formatTime(info.NextRun))
+		})
+	}
+}
+
+func runFlags(fs *flag.FlagSet) func(e *env, a []string) error {
+	bypass := fs.Bool("bypass", false, "do not run the Execution Middleware of the Task")
+	return func(e *env, a []string) error {
+		if err := args(a, 1); err != nil {
+			return err
+		}
+		query := url.Values{}
+		if *bypass {
+			query.Set("bypass", "true")
+		}
+		var resp admin.RunResponse
+		if err := e.client.do(http.MethodPost, query, &resp, "tasks", a[0], "run"); err != nil {
+			return err
+		}
+		return e.print(resp, func(w io.Writer) { fmt.Fprintln(w, resp.Instance) })
+	}
+}
+
+func cancel(e *env, a []string) error {
+	if err := args(a, 2); err != nil {
+		return err
+	}
+	var resp admin.RunResponse
+	if err := e.client.do(http.MethodPost, nil, &resp, "tasks", a[0], "runs", a[1], "cancel"); err != nil {
+		return err
+	}
+	return e.print(resp, func(w io.Writer) { fmt.Fprintln(w, resp.Instance) })
+}
+
+func history(e *env, a []string) error {
+	if err := args(a, 1); err != nil {
+		return err
+	}
+	var recs []taskmanager.RunRecord
+	if err := e.client.do(http.MethodGet, nil, &recs, "tasks", a[0], "history"); err != nil {
+		return err
+	}
+	return e.print(recs, func(w io.Writer) {
+		fmt.Fprintln(w, "INSTANCE\tSCHEDULED\tSTART\tDURATION\tSTATE\tERROR")
+		for _, rec := range recs {
+			scheduled := formatTime(rec.Scheduled)
+			if rec.OutOfBand {
+				scheduled = "now"
+			}
+			duration := "-"
+			if !rec.Start.IsZero() && !rec.Finish.IsZero() {
+				duration = rec.Finish.Sub(rec.Start).String()
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", rec.InstanceID, scheduled, formatTime(rec.Start), duration, rec.State, dash(rec.Error))
+		}
+	})
+}
+
+func nextFlags(fs *flag.FlagSet) func(e *env, a []string) error {
+	count := fs.Int("count", 10, "number of run times to show")
+	return func(e *env, a []string) error {
+		if err := args(a, 1); err != nil {
+			return err
+		}
+		if *count < 1 {
+			return usageError{message: "-count must be at least 1"}
+		}
+		var resp admin.NextResponse
+		query := url.Values{"count": {strconv.Itoa(*count)}}
+		if err := e.client.do(http.MethodGet, query, &resp, "tasks", a[0], "next"); err != nil {
+			return err
+		}
+		return e.print(resp, func(w io.Writer) {
+			for _, t := range resp.Next {
+				fmt.Fprintln(w, formatTime(t))
+			}
+		})
+	}
+}
+
+func tags(e *env, a []string) error {
+	switch {
+	case len(a) == 0:
+		var resp []admin.TagsResponse
+		if err := e.client.do(http.MethodGet, nil, &resp, "tags"); err != nil {
+			return err
+		}
+		return e.print(resp, func(w io.Writer) {
+			fmt.Fprintln(w, "NAME\tREQUIRED\tHAVE")
+			for _, th := range resp {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", th.Name, dash(strings.Join(th.Required, ",")), dash(strings.Join(th.Have, ",")))
+			}
+		})
+	case len(a) == 1:
+		return tagHandler(e, http.MethodGet, "tags", a[0])
+	case len(a) == 3 && a[0] == "set":
+		return tagHandler(e, http.MethodPut, "tags", a[1], "have", a[2])
+	case len(a) == 3 && a[0] == "del":
+		return tagHandler(e, http.MethodDelete, "tags", a[1], "have", a[2])
+	default:
+		return usageError{message: "invalid arguments"}
+	}
+}
+
+func tagHandler(e *env, method string, path ...string) error {
+	var th admin.TagsResponse
+	if err := e.client.do(method, nil, &th, path...); err != nil {
+		return err
+	}
+	return e.print(th, func(w io.Writer) {
+		fmt.Fprintln(w, "NAME\tREQUIRED\tHAVE")
+		fmt.Fprintf(w, "%s\t%s\t%s\n", th.Name, dash(strings.Join(th.Required, ",")), dash(strings.Join(th.Have, ",")))
+	})
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
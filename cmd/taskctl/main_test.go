@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/admin"
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	executionmiddleware "github.com/Fishwaldo/go-taskmanager/middleware/executation"
+	"github.com/go-logr/logr"
+)
+
+var testTime = time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTaskctl(t *testing.T) {
+	s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(clock.NewFake(testTime)))
+	defer s.Shutdown(context.Background())
+	timer, _ := taskmanager.NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "export", timer, func(ctx context.Context) {})
+	_ = s.Start("export")
+	h := admin.NewHandler(s)
+	th := executionmiddleware.NewTagHandler()
+	h.AddTagHandler("database", th)
+
+	// Serve the admin API on a unix socket
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.StripPrefix("/admin", h))
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	taskctl := func(code int, args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-socket", socket}, args...)
+		if got := run(args, &stdout, &stderr); got != code {
+			t.Fatalf("taskctl %s exited with %d, not %d: %s", strings.Join(args, " "), got, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	out := taskctl(exitOK, "list")
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], "export") || !strings.Contains(lines[1], "SCHEDULED") {
+		t.Errorf("taskctl list printed:\n%s", out)
+	}
+	if out := taskctl(exitOK, "pause", "export"); !strings.Contains(out, "PAUSED") {
+		t.Errorf("taskctl pause printed:\n%s", out)
+	}
+
+	var next admin.NextResponse
+	if err := json.Unmarshal([]byte(taskctl(exitOK, "-o", "json", "next", "-count", "2", "export")), &next); err != nil {
+		t.Fatalf("taskctl next printed invalid JSON: %s", err.Error())
+	}
+	if len(next.Next) != 2 || !next.Next[1].Equal(testTime.Add(2*time.Hour)) {
+		t.Errorf("taskctl next printed %+v", next)
+	}
+	// Flags can also follow the ID
+	if err := json.Unmarshal([]byte(taskctl(exitOK, "-o", "json", "next", "export", "-count", "3")), &next); err != nil || len(next.Next) != 3 {
+		t.Errorf("taskctl next with -count after the ID printed %+v, %v", next, err)
+	}
+	taskctl(exitUsage, "next", "export", "-count", "3", "extra")
+
+	taskctl(exitOK, "tags", "set", "database", "db")
+	if !th.IsHaveTag("db") {
+		t.Errorf("taskctl tags set did not set the tag")
+	}
+	taskctl(exitOK, "tags", "del", "database", "db")
+	if th.IsHaveTag("db") {
+		t.Errorf("taskctl tags del did not delete the tag")
+	}
+
+	taskctl(exitNotFound, "describe", "missing")
+	taskctl(exitUsage, "describe")
+	taskctl(exitUsage, "frobnicate")
+	taskctl(exitUnavailable, "-socket", filepath.Join(t.TempDir(), "missing.sock"), "list")
+}
@@ -0,0 +1,509 @@
+// Package config creates Tasks from a declarative YAML or JSON document, such as:
+//
+//	tagHandlers:
+//	  database:
+//	    required: [db]
+//	tasks:
+//	  - id: nightly-backup
+//	    job: backup               # job type registered in the taskmanager.JobRegistry
+//	    params: {database: main}  # passed to the JobFactory as JSON
+//	    timer: {cron: "H 3 * * *"} # or every: 10s, once: 10s, or at: 2021-11-01T03:00:00Z
+//	                              # or a string such as "@daily", see taskmanager.ParseTimer
+//	    jitter: 1m                # delay runs by up to 1m, see taskmanager.WithJitter
+//	    priority: 10
+//	    labels: {team: ops}
+//	    timeout: 1h
+//	    hardKill: 1m
+//	    historySize: 20
+//	    misfire: {policy: runAll, threshold: 5m, limit: 3} # or skip or runOnce
+//	    start: true               # default
+//	    execution:
+//	      - type: concurrentJobBlocker
+//	      - type: hasTags
+//	        handler: database
+//	    retry:
+//	      - type: limit
+//	        max: 5
+//	      - type: exponential
+//	        initialInterval: 1s
+//	        maxInterval: 1m
+//	        handle: {jobError: true}
+//
+// Errors in the document are reported as Errors, pointing at the offending line and field.
+//
+// A Reloader applies the document again whenever it changes, or on SIGHUP, updating only the Tasks that changed.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	executionmiddleware "github.com/Fishwaldo/go-taskmanager/middleware/executation"
+	retrymiddleware "github.com/Fishwaldo/go-taskmanager/middleware/retry"
+	"github.com/cenkalti/backoff/v4"
+	"gopkg.in/yaml.v3"
+)
+
+//Config The Tasks described by a config document
+type Config struct {
+	Tasks []Task
+	// HasTagHandlers by name, so the tags they have can be set with SetHaveTags
+	TagHandlers map[string]*executionmiddleware.HasTagHandler
+	// Required tags of the TagHandlers reused from the Config last applied by a Reloader, by name, which are set by
+	// updateTagHandlers once the Config is applied
+	requiredTags map[string][]string
+}
+
+//Task A Task described by a config document
+type Task struct {
+	ID      string
+	JobType string
+	Params  json.RawMessage
+	// TimerSpec of the Timer
+	Timer string
+	// Start the Task once it is added
+	Start bool
+	// Options for Scheduler.AddJob, including the Middleware
+	Options []taskmanager.Option
+	// Line of the Task in the document
+	Line int
+	// Fingerprint of the Task and the tagHandlers it references, which changes whenever they do
+	Fingerprint string
+}
+
+//Load Parse the config document at path, see Parse
+func Load(path string, registry *taskmanager.JobRegistry) (*Config, error) {
+	return load(path, registry, nil)
+}
+
+// load parses the config document at path, reusing the HasTagHandlers in reuse by name, see parse
+func load(path string, registry *taskmanager.JobRegistry, reuse map[string]*executionmiddleware.HasTagHandler) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data, registry, reuse)
+}
+
+//Parse Parse a YAML or JSON config document. If registry is not nil, the job types of the Tasks must be
+//registered in it. Return Errors if the document is invalid.
+func Parse(data []byte, registry *taskmanager.JobRegistry) (*Config, error) {
+	return parse(data, registry, nil)
+}
+
+// parse parses a config document like Parse, but reuses the HasTagHandlers in reuse with the same name, so the tags
+// set on them and the Tasks using them are kept. Their have tags are left alone, and their required tags are only
+// changed by updateTagHandlers.
+func parse(data []byte, registry *taskmanager.JobRegistry, reuse map[string]*executionmiddleware.HasTagHandler) (*Config, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	cfg := &Config{TagHandlers: make(map[string]*executionmiddleware.HasTagHandler), requiredTags: make(map[string][]string)}
+	if len(doc.Content) == 0 {
+		return cfg, nil
+	}
+	d := &decoder{handlerNodes: make(map[string]*yaml.Node), reuse: reuse}
+	root := doc.Content[0]
+	var tasks *yaml.Node
+	d.mapping(root, "", map[string]func(*yaml.Node, string){
+		"tagHandlers": func(n *yaml.Node, path string) { d.tagHandlers(cfg, n, path) },
+		// Tasks reference the tagHandlers, which may come after them
+		"tasks": func(n *yaml.Node, path string) { tasks = n },
+	})
+	if tasks != nil {
+		ids := make(map[string]bool)
+		d.sequence(tasks, "tasks", func(i int, n *yaml.Node, path string) {
+			t := d.task(cfg, registry, n, path)
+			if t == nil {
+				return
+			}
+			if ids[t.ID] {
+				d.fail(n, path+".id", "duplicate task id %q", t.ID)
+			}
+			ids[t.ID] = true
+			cfg.Tasks = append(cfg.Tasks, *t)
+		})
+	}
+	if err := d.result(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// updateTagHandlers sets the required tags of the TagHandlers reused from a previous Config to those in the document
+func (c *Config) updateTagHandlers() {
+	for name, tags := range c.requiredTags {
+		th := c.TagHandlers[name]
+		want := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			want[tag] = true
+			th.SetRequiredTags(tag)
+		}
+		for _, tag := range th.RequiredTags() {
+			if !want[tag] {
+				th.DelRequiredTags(tag)
+			}
+		}
+	}
+}
+
+//Apply Add the Tasks to s with Scheduler.AddJob, starting those with Start set. s must have been created with
+//the JobRegistry given to Parse. Stops at the first Task that can not be added.
+func (c *Config) Apply(ctx context.Context, s *taskmanager.Scheduler) error {
+	for _, t := range c.Tasks {
+		if err := t.Add(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Add Add the Task to s with Scheduler.AddJob, starting it if Start is set
+func (t Task) Add(ctx context.Context, s *taskmanager.Scheduler) error {
+	if err := s.AddJob(ctx, t.ID, t.Timer, t.JobType, t.Params, t.Options...); err != nil {
+		return fmt.Errorf("line %d: task %s: %w", t.Line, t.ID, err)
+	}
+	if t.Start {
+		if err := s.Start(t.ID); err != nil {
+			return fmt.Errorf("line %d: task %s: %w", t.Line, t.ID, err)
+		}
+	}
+	return nil
+}
+
+func (d *decoder) tagHandlers(cfg *Config, n *yaml.Node, path string) {
+	if n.Kind != yaml.MappingNode {
+		d.fail(n, path, "expected a mapping")
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		name := n.Content[i].Value
+		th, reused := d.reuse[name]
+		if reused {
+			cfg.requiredTags[name] = []string{}
+		} else {
+			th = executionmiddleware.NewTagHandler()
+		}
+		d.handlerNodes[name] = n.Content[i+1]
+		d.mapping(n.Content[i+1], join(path, name), map[string]func(*yaml.Node, string){
+			"required": func(n *yaml.Node, path string) {
+				for _, tag := range d.strings(n, path) {
+					if reused {
+						cfg.requiredTags[name] = append(cfg.requiredTags[name], tag)
+					} else {
+						th.SetRequiredTags(tag)
+					}
+				}
+			},
+			"have": func(n *yaml.Node, path string) {
+				for _, tag := range d.strings(n, path) {
+					if !reused {
+						th.SetHaveTags(tag)
+					}
+				}
+			},
+		})
+		cfg.TagHandlers[name] = th
+	}
+}
+
+func (d *decoder) task(cfg *Config, registry *taskmanager.JobRegistry, n *yaml.Node, path string) *Task {
+	t := &Task{Start: true, Line: n.Line}
+	d.handlerRefs = nil
+	ok := d.mapping(n, path, map[string]func(*yaml.Node, string){
+		"id": func(n *yaml.Node, path string) { t.ID = d.str(n, path) },
+		"job": func(n *yaml.Node, path string) {
+			t.JobType = d.str(n, path)
+			if registry != nil && t.JobType != "" && !registry.Registered(t.JobType) {
+				d.fail(n, path, "job type %q is not registered", t.JobType)
+			}
+		},
+		"params": func(n *yaml.Node, path string) {
+			var params interface{}
+			if err := n.Decode(&params); err != nil {
+				d.fail(n, path, "%s", err.Error())
+				return
+			}
+			data, err := json.Marshal(params)
+			if err != nil {
+				d.fail(n, path, "can not be encoded as JSON: %s", err.Error())
+				return
+			}
+			t.Params = data
+		},
+		"timer":    func(n *yaml.Node, path string) { t.Timer = d.timer(n, path) },
+		"priority": func(n *yaml.Node, path string) { t.Options = append(t.Options, taskmanager.WithPriority(d.integer(n, path))) },
+		"labels":   func(n *yaml.Node, path string) { t.Options = append(t.Options, taskmanager.WithLabels(d.stringMap(n, path))) },
+		"timeout":  func(n *yaml.Node, path string) { t.Options = append(t.Options, taskmanager.WithRunTimeout(d.duration(n, path))) },
+		"hardKill": func(n *yaml.Node, path string) { t.Options = append(t.Options, taskmanager.WithRunHardKill(d.duration(n, path))) },
+		"jitter":   func(n *yaml.Node, path string) { t.Options = append(t.Options, taskmanager.WithJitter(d.duration(n, path))) },
+		"historySize": func(n *yaml.Node, path string) {
+			t.Options = append(t.Options, taskmanager.WithHistorySize(d.integer(n, path)))
+		},
+		"start": func(n *yaml.Node, path string) { t.Start = d.boolean(n, path) },
+		"misfire": func(n *yaml.Node, path string) {
+			if opt := d.misfire(n, path); opt != nil {
+				t.Options = append(t.Options, opt)
+			}
+		},
+		"execution": func(n *yaml.Node, path string) {
+			d.sequence(n, path, func(i int, n *yaml.Node, path string) {
+				if mw := d.executionMiddleware(cfg, n, path); mw != nil {
+					t.Options = append(t.Options, taskmanager.WithExecutationMiddleWare(mw))
+				}
+			})
+		},
+		"retry": func(n *yaml.Node, path string) {
+			d.sequence(n, path, func(i int, n *yaml.Node, path string) {
+				if mw := d.retryMiddleware(n, path); mw != nil {
+					t.Options = append(t.Options, taskmanager.WithRetryMiddleWare(mw))
+				}
+			})
+		},
+	})
+	if !ok {
+		return nil
+	}
+	if t.ID == "" {
+		d.fail(n, join(path, "id"), "is required")
+	}
+	if t.JobType == "" {
+		d.fail(n, join(path, "job"), "is required")
+	}
+	if t.Timer == "" {
+		d.fail(n, join(path, "timer"), "is required")
+	}
+	t.Fingerprint = d.fingerprint(n)
+	return t
+}
+
+// fingerprint returns the Task n, and the tagHandlers it references, encoded as JSON
+func (d *decoder) fingerprint(n *yaml.Node) string {
+	var fp struct {
+		Task     interface{}
+		Handlers map[string]interface{} `json:",omitempty"`
+	}
+	if err := n.Decode(&fp.Task); err != nil {
+		return ""
+	}
+	for _, name := range d.handlerRefs {
+		var handler interface{}
+		if hn := d.handlerNodes[name]; hn != nil && hn.Decode(&handler) == nil {
+			if fp.Handlers == nil {
+				fp.Handlers = make(map[string]interface{})
+			}
+			fp.Handlers[name] = handler
+		}
+	}
+	data, err := json.Marshal(fp)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// misfire returns the WithMisfirePolicy Option described by the misfire mapping n
+func (d *decoder) misfire(n *yaml.Node, path string) taskmanager.Option {
+	policy := taskmanager.MisfirePolicy_RunOnce
+	var threshold time.Duration
+	limit := 1
+	hasThreshold := false
+	ok := d.mapping(n, path, map[string]func(*yaml.Node, string){
+		"policy": func(n *yaml.Node, path string) {
+			switch p := d.str(n, path); p {
+			case "skip":
+				policy = taskmanager.MisfirePolicy_Skip
+			case "runOnce":
+				policy = taskmanager.MisfirePolicy_RunOnce
+			case "runAll":
+				policy = taskmanager.MisfirePolicy_RunAll
+			default:
+				d.fail(n, path, "unknown misfire policy %q, must be skip, runOnce or runAll", p)
+			}
+		},
+		"threshold": func(n *yaml.Node, path string) {
+			hasThreshold = true
+			threshold = d.duration(n, path)
+		},
+		"limit": func(n *yaml.Node, path string) {
+			if limit = d.integer(n, path); limit < 1 {
+				d.fail(n, path, "must be at least 1")
+			}
+		},
+	})
+	if !ok {
+		return nil
+	}
+	if !hasThreshold {
+		d.fail(n, join(path, "threshold"), "is required")
+	}
+	return taskmanager.WithMisfirePolicy(policy, threshold, limit)
+}
+
+// timer returns the TimerSpec of the timer n, which is either a string parsed by taskmanager.ParseTimer, or a mapping
+// that must have exactly one of cron, every, once or at
+func (d *decoder) timer(n *yaml.Node, path string) string {
+	if n.Kind == yaml.ScalarNode {
+		spec := d.str(n, path)
+		if _, err := taskmanager.ParseTimer(spec); err != nil {
+			d.fail(n, path, "%s", err.Error())
+		}
+		return spec
+	}
+	var spec string
+	set := 0
+	d.mapping(n, path, map[string]func(*yaml.Node, string){
+		"cron": func(n *yaml.Node, path string) {
+			set++
+			expr := d.str(n, path)
+			if timer, err := taskmanager.ParseTimer(expr); err != nil {
+				d.fail(n, path, "%s", err.Error())
+			} else if _, ok := timer.(*taskmanager.Cron); !ok {
+				d.fail(n, path, "%q is not a cron expression", expr)
+			}
+			spec = expr
+		},
+		"every": func(n *yaml.Node, path string) {
+			set++
+			spec = "@every " + d.duration(n, path).String()
+		},
+		"once": func(n *yaml.Node, path string) {
+			set++
+			spec = "@after " + d.duration(n, path).String()
+		},
+		"at": func(n *yaml.Node, path string) {
+			set++
+			s := d.str(n, path)
+			at, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				d.fail(n, path, "%q is not a RFC3339 time", s)
+			}
+			spec = "@at " + at.Format(time.RFC3339Nano)
+		},
+	})
+	if n.Kind == yaml.MappingNode && set != 1 {
+		d.fail(n, path, "must have exactly one of cron, every, once or at")
+	}
+	return spec
+}
+
+func (d *decoder) middlewareType(n *yaml.Node, path string) string {
+	if n.Kind != yaml.MappingNode {
+		d.fail(n, path, "expected a mapping")
+		return ""
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == "type" {
+			return d.str(n.Content[i+1], join(path, "type"))
+		}
+	}
+	d.fail(n, join(path, "type"), "is required")
+	return ""
+}
+
+func (d *decoder) executionMiddleware(cfg *Config, n *yaml.Node, path string) taskmanager.ExecutionMiddleWare {
+	typeField := func(*yaml.Node, string) {}
+	switch mwType := d.middlewareType(n, path); mwType {
+	case "":
+		return nil
+	case "concurrentJobBlocker":
+		d.mapping(n, path, map[string]func(*yaml.Node, string){"type": typeField})
+		return executionmiddleware.NewCJLock()
+	case "hasTags":
+		var th *executionmiddleware.HasTagHandler
+		found := false
+		d.mapping(n, path, map[string]func(*yaml.Node, string){
+			"type": typeField,
+			"handler": func(n *yaml.Node, path string) {
+				found = true
+				name := d.str(n, path)
+				d.handlerRefs = append(d.handlerRefs, name)
+				if th = cfg.TagHandlers[name]; th == nil {
+					d.fail(n, path, "tag handler %q is not defined in tagHandlers", name)
+				}
+			},
+		})
+		if !found {
+			d.fail(n, join(path, "handler"), "is required")
+		}
+		if th == nil {
+			return nil
+		}
+		return th
+	default:
+		d.fail(n, join(path, "type"), "unknown execution middleware %q, must be concurrentJobBlocker or hasTags", mwType)
+		return nil
+	}
+}
+
+func (d *decoder) retryMiddleware(n *yaml.Node, path string) taskmanager.RetryMiddleware {
+	var handle map[string]bool
+	fields := map[string]func(*yaml.Node, string){
+		"type": func(*yaml.Node, string) {},
+		"handle": func(n *yaml.Node, path string) {
+			handle = make(map[string]bool)
+			d.mapping(n, path, map[string]func(*yaml.Node, string){
+				"panic":    func(n *yaml.Node, path string) { handle["panic"] = d.boolean(n, path) },
+				"overlap":  func(n *yaml.Node, path string) { handle["overlap"] = d.boolean(n, path) },
+				"deferred": func(n *yaml.Node, path string) { handle["deferred"] = d.boolean(n, path) },
+				"jobError": func(n *yaml.Node, path string) { handle["jobError"] = d.boolean(n, path) },
+				"timeout":  func(n *yaml.Node, path string) { handle["timeout"] = d.boolean(n, path) },
+			})
+		},
+	}
+	var mw taskmanager.RetryMiddleware
+	var opts *retrymiddleware.RetryMiddlewareOptions
+	switch mwType := d.middlewareType(n, path); mwType {
+	case "":
+		return nil
+	case "constant":
+		interval := 1 * time.Second
+		fields["interval"] = func(n *yaml.Node, path string) { interval = d.duration(n, path) }
+		d.mapping(n, path, fields)
+		cbo := retrymiddleware.NewRetryConstantBackoff(interval)
+		mw, opts = cbo, &cbo.RetryMiddlewareOptions
+	case "exponential":
+		bo := backoff.NewExponentialBackOff()
+		fields["initialInterval"] = func(n *yaml.Node, path string) { bo.InitialInterval = d.duration(n, path) }
+		fields["maxInterval"] = func(n *yaml.Node, path string) { bo.MaxInterval = d.duration(n, path) }
+		fields["maxElapsedTime"] = func(n *yaml.Node, path string) { bo.MaxElapsedTime = d.duration(n, path) }
+		fields["multiplier"] = func(n *yaml.Node, path string) { bo.Multiplier = d.float(n, path) }
+		fields["randomizationFactor"] = func(n *yaml.Node, path string) { bo.RandomizationFactor = d.float(n, path) }
+		d.mapping(n, path, fields)
+		ebo := retrymiddleware.NewRetryExponentialBackoff(bo)
+		mw, opts = ebo, &ebo.RetryMiddlewareOptions
+	case "limit":
+		max := -1
+		fields["max"] = func(n *yaml.Node, path string) { max = d.integer(n, path) }
+		d.mapping(n, path, fields)
+		if max < 0 {
+			d.fail(n, join(path, "max"), "is required")
+			return nil
+		}
+		rcl := retrymiddleware.NewRetryRetryCountLimit(max)
+		mw, opts = rcl, &rcl.RetryMiddlewareOptions
+	default:
+		d.fail(n, join(path, "type"), "unknown retry middleware %q, must be constant, exponential or limit", mwType)
+		return nil
+	}
+	for state, val := range handle {
+		switch state {
+		case "panic":
+			opts.HandlePanic(val)
+		case "overlap":
+			opts.HandleOverlap(val)
+		case "deferred":
+			opts.HandleDeferred(val)
+		case "jobError":
+			opts.HandleJobError(val)
+		case "timeout":
+			opts.HandleTimeout(val)
+		}
+	}
+	return mw
+}
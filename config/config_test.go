@@ -0,0 +1,144 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/go-logr/logr"
+)
+
+func testRegistry(params chan json.RawMessage) *taskmanager.JobRegistry {
+	registry := taskmanager.NewJobRegistry()
+	_ = registry.Register("backup", func(p json.RawMessage) (func(context.Context) error, error) {
+		params <- p
+		return func(ctx context.Context) error { return nil }, nil
+	})
+	return registry
+}
+
+const testYAML = `
+tagHandlers:
+  database:
+    required: [db]
+    have: [db]
+tasks:
+  - id: nightly-backup
+    job: backup
+    params: {database: main, tables: [users]}
+    timer: {cron: "0 3 * * *"}
+    priority: 10
+    labels: {team: ops}
+    timeout: 1h
+    execution:
+      - type: concurrentJobBlocker
+      - type: hasTags
+        handler: database
+    retry:
+      - type: limit
+        max: 5
+      - type: exponential
+        initialInterval: 1s
+        handle: {jobError: true}
+  - id: hourly
+    job: backup
+    timer: {every: 1h}
+    jitter: 10m
+    start: false
+    misfire: {policy: skip, threshold: 5m}
+`
+
+func TestParse(t *testing.T) {
+	params := make(chan json.RawMessage, 10)
+	registry := testRegistry(params)
+	cfg, err := Parse([]byte(testYAML), registry)
+	if err != nil {
+		t.Fatalf("Parse Returned Error: %s", err.Error())
+	}
+	if len(cfg.Tasks) != 2 || cfg.TagHandlers["database"] == nil || !cfg.TagHandlers["database"].IsHaveTag("db") {
+		t.Fatalf("Parsed %+v", cfg)
+	}
+	if task := cfg.Tasks[0]; task.ID != "nightly-backup" || task.Timer != "0 3 * * *" || !task.Start || task.Line != 7 {
+		t.Errorf("Parsed Task %+v", task)
+	}
+	if task := cfg.Tasks[1]; task.Timer != "@every 1h0m0s" || task.Start {
+		t.Errorf("Parsed Task %+v", task)
+	}
+
+	s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithJobRegistry(registry))
+	defer s.Shutdown(context.Background())
+	if err := cfg.Apply(context.Background(), s); err != nil {
+		t.Fatalf("Apply Returned Error: %s", err.Error())
+	}
+	if p := <-params; string(p) != `{"database":"main","tables":["users"]}` {
+		t.Errorf("Job params are %s", p)
+	}
+	info, _ := s.Describe("nightly-backup")
+	if info.State != taskmanager.TaskState_Scheduled || info.Priority != 10 || info.Labels["team"] != "ops" || len(info.Middlewares) != 4 {
+		t.Errorf("nightly-backup Described as %+v", info)
+	}
+	if info, _ := s.Describe("hourly"); info.State != taskmanager.TaskState_Stopped {
+		t.Errorf("hourly State is %s, not STOPPED", info.State)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	doc := "{\n\t\"tasks\": [\n\t\t{\"id\": \"once\", \"job\": \"backup\", \"timer\": {\"once\": \"10s\"}},\n\t\t{\"id\": \"repeat\", \"job\": \"backup\", \"timer\": \"R5/2026-01-01T00:00:00Z/PT1H\"}\n\t]\n}\n"
+	cfg, err := Parse([]byte(doc), nil)
+	if err != nil {
+		t.Fatalf("Parse Returned Error: %s", err.Error())
+	}
+	if len(cfg.Tasks) != 2 || cfg.Tasks[0].Timer != "@after "+(10*time.Second).String() || cfg.Tasks[1].Timer != "R5/2026-01-01T00:00:00Z/PT1H" {
+		t.Errorf("Parsed %+v", cfg.Tasks)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	doc := `
+tasks:
+  - id: a
+    job: missing
+    timer: {every: often}
+    execution:
+      - type: hasTags
+        handler: nope
+  - id: a
+    job: backup
+    timer: {cron: "0 3 * * *", every: 1h}
+    retry:
+      - type: limit
+    colour: blue
+  - id: c
+    job: backup
+    timer: "R5/PT1H"
+`
+	_, err := Parse([]byte(doc), testRegistry(make(chan json.RawMessage, 10)))
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Parse did not return Errors: %v", err)
+	}
+	want := []struct {
+		line  int
+		field string
+	}{
+		{4, "tasks[0].job"},
+		{5, "tasks[0].timer.every"},
+		{8, "tasks[0].execution[0].handler"},
+		{9, "tasks[1].id"},
+		{11, "tasks[1].timer"},
+		{13, "tasks[1].retry[0].max"},
+		{14, "tasks[1].colour"},
+		{17, "tasks[2].timer"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Parse returned %d Errors, not %d:\n%s", len(errs), len(want), errs.Error())
+	}
+	for i, w := range want {
+		if errs[i].Line != w.line || errs[i].Field != w.field {
+			t.Errorf("Error %d is %q, expected line %d field %s", i, errs[i].Error(), w.line, w.field)
+		}
+	}
+}
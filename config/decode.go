@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	executionmiddleware "github.com/Fishwaldo/go-taskmanager/middleware/executation"
+	"gopkg.in/yaml.v3"
+)
+
+//Error A error in a config document, pointing at the offending line and field
+type Error struct {
+	Line   int
+	Column int
+	// Path of the field, such as tasks[0].timer.cron
+	Field   string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Field, e.Message)
+}
+
+//Errors Every Error found in a config document, in document order
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// decoder walks a yaml.Node tree, collecting Errors instead of stopping at the first
+type decoder struct {
+	errs Errors
+	// tagHandlers by name, and those referenced by the Task being decoded, for Task.Fingerprint
+	handlerNodes map[string]*yaml.Node
+	handlerRefs  []string
+	// HasTagHandlers of the Config last applied by a Reloader, reused by name
+	reuse map[string]*executionmiddleware.HasTagHandler
+}
+
+func (d *decoder) fail(n *yaml.Node, field string, format string, args ...interface{}) {
+	d.errs = append(d.errs, &Error{Line: n.Line, Column: n.Column, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *decoder) result() error {
+	if len(d.errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(d.errs, func(i, j int) bool {
+		if d.errs[i].Line != d.errs[j].Line {
+			return d.errs[i].Line < d.errs[j].Line
+		}
+		return d.errs[i].Column < d.errs[j].Column
+	})
+	return d.errs
+}
+
+func join(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// mapping calls fields[key] for every key of the mapping n, reporting unknown and duplicate keys
+func (d *decoder) mapping(n *yaml.Node, path string, fields map[string]func(val *yaml.Node, path string)) bool {
+	if n.Kind != yaml.MappingNode {
+		d.fail(n, path, "expected a mapping")
+		return false
+	}
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, val := n.Content[i], n.Content[i+1]
+		field := join(path, key.Value)
+		fn, ok := fields[key.Value]
+		if !ok {
+			d.fail(key, field, "unknown field")
+			continue
+		}
+		if seen[key.Value] {
+			d.fail(key, field, "duplicate field")
+			continue
+		}
+		seen[key.Value] = true
+		fn(val, field)
+	}
+	return true
+}
+
+// sequence calls fn for every item of the sequence n
+func (d *decoder) sequence(n *yaml.Node, path string, fn func(i int, item *yaml.Node, path string)) {
+	if n.Kind != yaml.SequenceNode {
+		d.fail(n, path, "expected a list")
+		return
+	}
+	for i, item := range n.Content {
+		fn(i, item, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (d *decoder) scalar(n *yaml.Node, path string) (string, bool) {
+	if n.Kind != yaml.ScalarNode {
+		d.fail(n, path, "expected a value")
+		return "", false
+	}
+	return n.Value, true
+}
+
+func (d *decoder) str(n *yaml.Node, path string) string {
+	s, _ := d.scalar(n, path)
+	return s
+}
+
+func (d *decoder) integer(n *yaml.Node, path string) int {
+	s, ok := d.scalar(n, path)
+	if !ok {
+		return 0
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		d.fail(n, path, "%q is not a integer", s)
+	}
+	return v
+}
+
+func (d *decoder) float(n *yaml.Node, path string) float64 {
+	s, ok := d.scalar(n, path)
+	if !ok {
+		return 0
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		d.fail(n, path, "%q is not a number", s)
+	}
+	return v
+}
+
+func (d *decoder) boolean(n *yaml.Node, path string) bool {
+	s, ok := d.scalar(n, path)
+	if !ok {
+		return false
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		d.fail(n, path, "%q is not true or false", s)
+	}
+	return v
+}
+
+func (d *decoder) duration(n *yaml.Node, path string) time.Duration {
+	s, ok := d.scalar(n, path)
+	if !ok {
+		return 0
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		d.fail(n, path, "%q is not a duration", s)
+		return 0
+	}
+	if v < 0 {
+		d.fail(n, path, "must not be negative")
+	}
+	return v
+}
+
+func (d *decoder) strings(n *yaml.Node, path string) []string {
+	var vals []string
+	d.sequence(n, path, func(i int, item *yaml.Node, path string) {
+		if s, ok := d.scalar(item, path); ok {
+			vals = append(vals, s)
+		}
+	})
+	return vals
+}
+
+func (d *decoder) stringMap(n *yaml.Node, path string) map[string]string {
+	if n.Kind != yaml.MappingNode {
+		d.fail(n, path, "expected a mapping")
+		return nil
+	}
+	vals := make(map[string]string, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if s, ok := d.scalar(n.Content[i+1], join(path, n.Content[i].Value)); ok {
+			vals[n.Content[i].Value] = s
+		}
+	}
+	return vals
+}
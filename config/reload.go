@@ -0,0 +1,253 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	executionmiddleware "github.com/Fishwaldo/go-taskmanager/middleware/executation"
+	"github.com/go-logr/logr"
+)
+
+//ReloadResult The IDs of the Tasks a Reload added, changed, removed or left unchanged, each sorted
+type ReloadResult struct {
+	Added     []string
+	Changed   []string
+	Removed   []string
+	Unchanged []string
+}
+
+//Reloader Applies a config document to a Scheduler, and applies it again whenever it changes, updating only the
+//Tasks that changed. Tasks that were not added by the Reloader are never removed by it.
+type Reloader struct {
+	mx        sync.Mutex
+	scheduler *taskmanager.Scheduler
+	registry  *taskmanager.JobRegistry
+	path      string
+	log       logr.Logger
+	// Fingerprints of the Tasks added by the Reloader, by ID
+	applied map[string]string
+	config  *Config
+	// Modification time and size of the document when it was last read
+	modTime time.Time
+	size    int64
+}
+
+//NewReloader Returns a Reloader of the config document at path. s must have been created with registry, see
+//taskmanager.WithJobRegistry. Nothing is applied until Reload or Watch is called.
+func NewReloader(s *taskmanager.Scheduler, path string, registry *taskmanager.JobRegistry, log logr.Logger) *Reloader {
+	return &Reloader{
+		scheduler: s,
+		registry:  registry,
+		path:      path,
+		log:       log.WithValues("config", path),
+		applied:   make(map[string]string),
+	}
+}
+
+//Config Returns the Config that was last applied, or nil if none has been. Use its TagHandlers to set the tags
+//that the Tasks have. TagHandlers are kept across Reloads by name, so the tags set on them stay: their required tags
+//follow the document, but the have tags in the document only apply to a TagHandler that is new.
+func (r *Reloader) Config() *Config {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return r.config
+}
+
+//Reload Read the config document and apply the differences to the Scheduler:
+//
+//	- Tasks that are new are added, and started if Start is set
+//	- Tasks that changed are updated in place with Scheduler.UpdateJob, keeping their run state, statistics and
+//	  History. They are started if Start is set and they are stopped.
+//	- Tasks that were removed from the document are removed from the Scheduler
+//	- Tasks that did not change are left alone
+//
+//Tasks that are already in the Scheduler when they first appear in the document, such as those restored from a
+//Store, are updated rather than added. Everything is checked before the Scheduler is changed, then Tasks are added,
+//updated and finally removed. If the document is invalid, a Job can not be created, or adding or updating a Task
+//fails, the Tasks already added or updated are put back as they were, nothing is removed, and the error is
+//returned with a empty ReloadResult.
+func (r *Reloader) Reload(ctx context.Context) (ReloadResult, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var result ReloadResult
+	if fi, err := os.Stat(r.path); err == nil {
+		r.modTime, r.size = fi.ModTime(), fi.Size()
+	}
+	var reuse map[string]*executionmiddleware.HasTagHandler
+	if r.config != nil {
+		reuse = r.config.TagHandlers
+	}
+	cfg, err := load(r.path, r.registry, reuse)
+	if err != nil {
+		r.log.Error(err, "Config Reload Rejected")
+		return ReloadResult{}, err
+	}
+
+	// Work out what changed, and check every Job can be created, before touching the Scheduler
+	var added, changed []Task
+	var removed []string
+	ids := make(map[string]bool, len(cfg.Tasks))
+	for _, t := range cfg.Tasks {
+		ids[t.ID] = true
+		fp, managed := r.applied[t.ID]
+		_, err := r.scheduler.GetSchedule(t.ID)
+		exists := err == nil
+		if managed && fp == t.Fingerprint && exists {
+			result.Unchanged = append(result.Unchanged, t.ID)
+			continue
+		}
+		if _, err := r.registry.Build(t.JobType, t.Params); err != nil {
+			err = fmt.Errorf("line %d: task %s: %w", t.Line, t.ID, err)
+			r.log.Error(err, "Config Reload Rejected")
+			return ReloadResult{}, err
+		}
+		if exists {
+			changed = append(changed, t)
+		} else {
+			added = append(added, t)
+		}
+	}
+	for id := range r.applied {
+		if !ids[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	// Apply the changes, putting back those already applied if one fails
+	var undo []func()
+	rollback := func(err error) (ReloadResult, error) {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		r.log.Error(err, "Config Reload Failed, Changes Rolled Back")
+		return ReloadResult{}, err
+	}
+	for _, t := range added {
+		id := t.ID
+		if err := r.scheduler.AddJob(ctx, t.ID, t.Timer, t.JobType, t.Params, t.Options...); err != nil {
+			return rollback(fmt.Errorf("line %d: task %s: %w", t.Line, t.ID, err))
+		}
+		undo = append(undo, func() { r.undoAdd(id) })
+		if t.Start {
+			if err := r.scheduler.Start(t.ID); err != nil {
+				return rollback(fmt.Errorf("line %d: task %s: %w", t.Line, t.ID, err))
+			}
+		}
+		result.Added = append(result.Added, t.ID)
+	}
+	for _, t := range changed {
+		undo = append(undo, r.undoUpdate(t.ID))
+		if err := r.update(t); err != nil {
+			return rollback(err)
+		}
+		result.Changed = append(result.Changed, t.ID)
+	}
+	for _, id := range removed {
+		if err := r.scheduler.Remove(id); err != nil {
+			r.log.Error(err, "Removing Task Failed", "jobid", id)
+		}
+		result.Removed = append(result.Removed, id)
+	}
+
+	applied := make(map[string]string, len(cfg.Tasks))
+	for _, t := range cfg.Tasks {
+		applied[t.ID] = t.Fingerprint
+	}
+	r.applied = applied
+	cfg.updateTagHandlers()
+	r.config = cfg
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Changed)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Unchanged)
+	r.log.Info("Config Reloaded", "added", result.Added, "changed", result.Changed, "removed", result.Removed, "unchanged", len(result.Unchanged))
+	return result, nil
+}
+
+// update updates the Task t in the Scheduler in place, starting it if Start is set and it is stopped
+func (r *Reloader) update(t Task) error {
+	if err := r.scheduler.UpdateJob(t.ID, t.Timer, t.JobType, t.Params, t.Options...); err != nil {
+		return fmt.Errorf("line %d: task %s: %w", t.Line, t.ID, err)
+	}
+	if !t.Start {
+		return nil
+	}
+	if info, err := r.scheduler.Describe(t.ID); err == nil && info.State == taskmanager.TaskState_Stopped {
+		if err := r.scheduler.Start(t.ID); err != nil {
+			return fmt.Errorf("line %d: task %s: %w", t.Line, t.ID, err)
+		}
+	}
+	return nil
+}
+
+// undoAdd removes the Task with the given id added by a Reload that failed
+func (r *Reloader) undoAdd(id string) {
+	if err := r.scheduler.Remove(id); err != nil {
+		r.log.Error(err, "Rolling Back Added Task Failed", "jobid", id)
+	}
+}
+
+// undoUpdate returns a func that puts the Task with the given id back as it was before a Reload that failed updated
+// it, with every Option and the state of its Middleware
+func (r *Reloader) undoUpdate(id string) func() {
+	info, err := r.scheduler.Describe(id)
+	if err != nil {
+		return func() {}
+	}
+	def, err := r.scheduler.Definition(id)
+	if err != nil {
+		return func() {}
+	}
+	return func() {
+		if err := r.scheduler.RestoreDefinition(def); err != nil {
+			r.log.Error(err, "Rolling Back Updated Task Failed", "jobid", id)
+		}
+		if info.State == taskmanager.TaskState_Stopped {
+			_ = r.scheduler.Stop(id)
+		}
+	}
+}
+
+//Watch Reload the config document whenever the process receives SIGHUP, or the modification time or size of the
+//document changes, which is checked every interval. Reloads that fail are logged, and leave the Scheduler as it
+//was. Returns when ctx is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sig:
+			r.log.Info("Received SIGHUP, Reloading Config")
+			_, _ = r.Reload(ctx)
+		case <-ticker.C:
+			if r.modified() {
+				r.log.Info("Config Changed, Reloading")
+				_, _ = r.Reload(ctx)
+			}
+		}
+	}
+}
+
+// modified returns true if the modification time or size of the document changed since it was last read
+func (r *Reloader) modified() bool {
+	fi, err := os.Stat(r.path)
+	if err != nil {
+		return false
+	}
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	return !fi.ModTime().Equal(r.modTime) || fi.Size() != r.size
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/go-logr/logr"
+)
+
+func TestReload(t *testing.T) {
+	testTime := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	registry := taskmanager.NewJobRegistry()
+	_ = registry.Register("noop", func(p json.RawMessage) (func(context.Context) error, error) {
+		var params struct{ Fail bool }
+		if p != nil {
+			if err := json.Unmarshal(p, &params); err != nil {
+				return nil, err
+			}
+		}
+		if params.Fail {
+			return nil, errors.New("test error")
+		}
+		return func(ctx context.Context) error { return nil }, nil
+	})
+	// flaky Jobs can only be created every other time, so they pass the checks of Reload and fail to be applied
+	builds := 0
+	_ = registry.Register("flaky", func(p json.RawMessage) (func(context.Context) error, error) {
+		builds++
+		if builds%2 == 0 {
+			return nil, errors.New("test error")
+		}
+		return func(ctx context.Context) error { return nil }, nil
+	})
+	fc := clock.NewFake(testTime)
+	s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(fc), taskmanager.WithJobRegistry(registry))
+	defer s.Shutdown(context.Background())
+
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	r := NewReloader(s, path, registry, logr.Discard())
+	reload := func(doc string) (ReloadResult, error) {
+		if err := ioutil.WriteFile(path, []byte(doc), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return r.Reload(context.Background())
+	}
+
+	result, err := reload(`
+tasks:
+  - {id: a, job: noop, timer: {every: 1h}}
+  - {id: b, job: noop, timer: {every: 1h}}
+  - {id: c, job: noop, timer: {every: 1h}}
+`)
+	if err != nil {
+		t.Fatalf("Reload Returned Error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(result.Added, []string{"a", "b", "c"}) {
+		t.Errorf("Reload Result is %+v", result)
+	}
+	_ = s.Pause("a")
+	if _, err := s.RunNow("b", taskmanager.WithRunBypassMiddleware()); err != nil {
+		t.Fatalf("RunNow Returned Error: %s", err.Error())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if history, _ := s.History("b"); len(history) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the run to be recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// a is unchanged, b changes its Timer, c is removed and d is added
+	result, err = reload(`
+tasks:
+  - {id: a, job: noop, timer: {every: 1h}}
+  - {id: b, job: noop, timer: {every: 2h}, labels: {team: ops}}
+  - {id: d, job: noop, timer: {every: 1h}}
+`)
+	if err != nil {
+		t.Fatalf("Reload Returned Error: %s", err.Error())
+	}
+	want := ReloadResult{Added: []string{"d"}, Changed: []string{"b"}, Removed: []string{"c"}, Unchanged: []string{"a"}}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("Reload Result is %+v, not %+v", result, want)
+	}
+	if info, _ := s.Describe("a"); info.State != taskmanager.TaskState_Paused {
+		t.Errorf("Unchanged Task State is %s, not PAUSED", info.State)
+	}
+	info, _ := s.Describe("b")
+	if info.State != taskmanager.TaskState_Scheduled || !info.NextRun.Equal(testTime.Add(2*time.Hour)) || info.Labels["team"] != "ops" || info.Runs != 1 {
+		t.Errorf("Changed Task Described as %+v", info)
+	}
+	if _, err := s.GetSchedule("c"); err == nil {
+		t.Errorf("Removed Task is still in the Scheduler")
+	}
+
+	// A Job that can not be created rejects the whole document
+	_, err = reload(`
+tasks:
+  - {id: a, job: noop, timer: {every: 1h}}
+  - {id: e, job: noop, timer: {every: 1h}, params: {fail: true}}
+`)
+	if err == nil {
+		t.Fatalf("Reload of a broken Job did not return an error")
+	}
+	if _, err := s.GetSchedule("d"); err != nil {
+		t.Errorf("Rejected Reload removed a Task")
+	}
+	if _, err := s.GetSchedule("e"); err == nil {
+		t.Errorf("Rejected Reload added a Task")
+	}
+
+	// A Task that fails to be applied rolls back the changes already made
+	config := r.Config()
+	result, err = reload(`
+tasks:
+  - {id: b, job: noop, timer: {every: 3h}}
+  - {id: a, job: flaky, timer: {every: 1h}}
+  - {id: g, job: noop, timer: {every: 1h}}
+`)
+	if err == nil {
+		t.Fatalf("Reload of a Job that fails to be created did not return an error")
+	}
+	if !reflect.DeepEqual(result, ReloadResult{}) || r.Config() != config {
+		t.Errorf("Failed Reload returned %+v, and changed the Config", result)
+	}
+	if _, err := s.GetSchedule("g"); err == nil {
+		t.Errorf("Failed Reload added a Task")
+	}
+	if _, err := s.GetSchedule("d"); err != nil {
+		t.Errorf("Failed Reload removed a Task")
+	}
+	if info, _ := s.Describe("b"); info.Timer != "@every 2h0m0s" || info.Labels["team"] != "ops" {
+		t.Errorf("Failed Reload left b as %+v", info)
+	}
+
+	// So does a invalid document
+	if _, err = reload("tasks:\n  - {id: a, job: missing, timer: {every: 1h}}\n"); err == nil {
+		t.Fatalf("Reload of a invalid document did not return an error")
+	}
+	if _, err := s.GetSchedule("d"); err != nil {
+		t.Errorf("Rejected Reload removed a Task")
+	}
+}
+
+func TestReloadTagHandlers(t *testing.T) {
+	testTime := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	registry := taskmanager.NewJobRegistry()
+	_ = registry.Register("noop", func(p json.RawMessage) (func(context.Context) error, error) {
+		return func(ctx context.Context) error { return nil }, nil
+	})
+	s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(clock.NewFake(testTime)), taskmanager.WithJobRegistry(registry))
+	defer s.Shutdown(context.Background())
+
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	r := NewReloader(s, path, registry, logr.Discard())
+	reload := func(doc string) (ReloadResult, error) {
+		if err := ioutil.WriteFile(path, []byte(doc), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return r.Reload(context.Background())
+	}
+	if _, err := reload(`
+tagHandlers: {database: {required: [db]}}
+tasks:
+  - {id: a, job: noop, timer: {every: 1h}, execution: [{type: hasTags, handler: database}]}
+`); err != nil {
+		t.Fatalf("Reload Returned Error: %s", err.Error())
+	}
+	th := r.Config().TagHandlers["database"]
+	th.SetHaveTags("db")
+
+	// The TagHandler is kept, with the tags set on it, so the unchanged Task still uses it
+	result, err := reload(`
+tagHandlers: {database: {required: [db]}}
+tasks:
+  - {id: a, job: noop, timer: {every: 1h}, execution: [{type: hasTags, handler: database}]}
+  - {id: b, job: noop, timer: {every: 1h}}
+`)
+	if err != nil {
+		t.Fatalf("Reload Returned Error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(result.Unchanged, []string{"a"}) || r.Config().TagHandlers["database"] != th || !th.IsHaveTag("db") {
+		t.Errorf("Reload Result is %+v, and the TagHandler was replaced", result)
+	}
+
+	// Its required tags follow the document, once the Reload is applied
+	result, err = reload(`
+tagHandlers: {database: {required: [db, cache], have: [disk]}}
+tasks:
+  - {id: a, job: noop, timer: {every: 1h}, execution: [{type: hasTags, handler: database}]}
+`)
+	if err != nil {
+		t.Fatalf("Reload Returned Error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(result.Changed, []string{"a"}) || r.Config().TagHandlers["database"] != th {
+		t.Errorf("Reload Result is %+v, and the TagHandler was replaced", result)
+	}
+	if !reflect.DeepEqual(th.RequiredTags(), []string{"cache", "db"}) || !reflect.DeepEqual(th.HaveTags(), []string{"db"}) {
+		t.Errorf("TagHandler requires %v and has %v", th.RequiredTags(), th.HaveTags())
+	}
+	if _, err := reload("tagHandlers: {database: {required: [other]}}\ntasks:\n  - {id: a, job: missing, timer: {every: 1h}}\n"); err == nil {
+		t.Fatalf("Reload of a invalid document did not return an error")
+	}
+	if !reflect.DeepEqual(th.RequiredTags(), []string{"cache", "db"}) {
+		t.Errorf("Rejected Reload changed the required tags to %v", th.RequiredTags())
+	}
+}
+
+// countingMiddleware is a Execution Middleware that counts how often it is initialized
+type countingMiddleware struct {
+	initialized int32
+}
+
+func (mw *countingMiddleware) PreHandler(s *taskmanager.Task) (taskmanager.MWResult, error) {
+	return taskmanager.MWResult{Result: taskmanager.MWResult_NextMW}, nil
+}
+
+func (mw *countingMiddleware) PostHandler(s *taskmanager.Task, err error) taskmanager.MWResult {
+	return taskmanager.MWResult{Result: taskmanager.MWResult_NextMW}
+}
+
+func (mw *countingMiddleware) Reset(s *taskmanager.Task) {}
+
+func (mw *countingMiddleware) Initilize(s *taskmanager.Task) {
+	atomic.AddInt32(&mw.initialized, 1)
+}
+
+func TestReloadRollbackWithoutConfig(t *testing.T) {
+	testTime := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	registry := taskmanager.NewJobRegistry()
+	deadlines := make(chan time.Time, 1)
+	_ = registry.Register("deadline", func(p json.RawMessage) (func(context.Context) error, error) {
+		return func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			deadlines <- deadline
+			return nil
+		}, nil
+	})
+	// flaky Jobs fail to be created the third time, when the Reload updates them after checking them
+	builds := 0
+	_ = registry.Register("flaky", func(p json.RawMessage) (func(context.Context) error, error) {
+		builds++
+		if builds == 3 {
+			return nil, errors.New("test error")
+		}
+		return func(ctx context.Context) error { return nil }, nil
+	})
+	s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(clock.NewFake(testTime)), taskmanager.WithJobRegistry(registry))
+	defer s.Shutdown(context.Background())
+
+	// Tasks added without the Reloader, so there is no Config to roll back to
+	mw := &countingMiddleware{}
+	if err := s.AddJob(context.Background(), "x", "@every 1h", "deadline", nil, taskmanager.WithRunTimeout(1*time.Minute),
+		taskmanager.WithExecutationMiddleWare(mw), taskmanager.WithLabels(map[string]string{"team": "ops"})); err != nil {
+		t.Fatalf("AddJob Returned Error: %s", err.Error())
+	}
+	_ = s.Start("x")
+	if err := s.AddJob(context.Background(), "z", "@every 1h", "flaky", nil); err != nil {
+		t.Fatalf("AddJob Returned Error: %s", err.Error())
+	}
+
+	path := filepath.Join(t.TempDir(), "tasks.yaml")
+	r := NewReloader(s, path, registry, logr.Discard())
+	if err := ioutil.WriteFile(path, []byte(`
+tasks:
+  - {id: x, job: deadline, timer: {every: 2h}, timeout: 1h}
+  - {id: z, job: flaky, timer: {every: 2h}}
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(context.Background()); err == nil {
+		t.Fatalf("Reload of a Job that fails to be created did not return an error")
+	}
+
+	// x is back as it was, with its Middleware not initialized again, and its run timeout
+	info, _ := s.Describe("x")
+	if info.Timer != "@every 1h0m0s" || info.State != taskmanager.TaskState_Scheduled || info.Labels["team"] != "ops" ||
+		!reflect.DeepEqual(info.Middlewares, []string{"*config.countingMiddleware"}) || !info.NextRun.Equal(testTime.Add(1*time.Hour)) {
+		t.Errorf("Failed Reload left x as %+v", info)
+	}
+	if n := atomic.LoadInt32(&mw.initialized); n != 1 {
+		t.Errorf("Middleware was initialized %d times", n)
+	}
+	if _, err := s.RunNow("x", taskmanager.WithRunBypassMiddleware()); err != nil {
+		t.Fatalf("RunNow Returned Error: %s", err.Error())
+	}
+	select {
+	case deadline := <-deadlines:
+		if !deadline.Equal(testTime.Add(1 * time.Minute)) {
+			t.Errorf("x ran with a deadline of %s", deadline)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for x to run")
+	}
+}
@@ -0,0 +1,254 @@
+package taskmanager
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/Fishwaldo/go-taskmanager/joberrors"
+	schedmetrics "github.com/Fishwaldo/go-taskmanager/metrics"
+	"github.com/armon/go-metrics"
+	"github.com/go-logr/logr"
+)
+
+//StaleDispatchPolicy What to do with a run that waited in the dispatch queue for longer than the limit set with
+//WithMaxDispatchWait
+type StaleDispatchPolicy int
+
+const (
+	// StaleDispatch_Drop Skip the run, and schedule the next one from the Timer
+	StaleDispatch_Drop StaleDispatchPolicy = iota
+	// StaleDispatch_Defer Defer the run as if a Execution Middleware returned MWResult_Defer, passing a
+	// joberrors.Error_DeferedJob to the Retry Middleware
+	StaleDispatch_Defer
+)
+
+func (p StaleDispatchPolicy) String() string {
+	switch p {
+	case StaleDispatch_Drop:
+		return "Drop"
+	case StaleDispatch_Defer:
+		return "Defer"
+	default:
+		return "Unknown"
+	}
+}
+
+type dispatchRequest struct {
+	task *Task
+	// scheduled is the next run of the Task that was dispatched, and catchUp is set if it was dispatched for a misfire
+	scheduled time.Time
+	catchUp   bool
+	queued    time.Time
+	seq       uint64
+	// instanceID and runOpts are set for runs started with Scheduler.RunNow
+	instanceID string
+	runOpts    *runOptions
+	// score orders pending runs, see dispatcher.score
+	score float64
+}
+
+// dispatcher runs due Tasks on a fixed number of workers. Runs that become due while all workers
+// are busy wait in a pending queue, ordered by the priority of the Task and how long they have waited.
+type dispatcher struct {
+	mx          sync.Mutex
+	cond        *sync.Cond
+	pending     dispatchQueue
+	seq         uint64
+	maxWait     time.Duration
+	stalePolicy StaleDispatchPolicy
+	aging       time.Duration
+	clock       clock.Clock
+	log         logr.Logger
+	stopped     bool
+}
+
+func newDispatcher(workers int, maxWait time.Duration, stalePolicy StaleDispatchPolicy, aging time.Duration, clk clock.Clock, log logr.Logger) *dispatcher {
+	d := &dispatcher{
+		maxWait:     maxWait,
+		stalePolicy: stalePolicy,
+		aging:       aging,
+		clock:       clk,
+		log:         log,
+	}
+	d.cond = sync.NewCond(&d.mx)
+	for i := 0; i < workers; i++ {
+		go d.worker()
+	}
+	return d
+}
+
+// submit queues a Task that was scheduled to run at scheduled to be run by the next free worker
+func (d *dispatcher) submit(t *Task, scheduled time.Time, catchUp bool) {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if d.stopped {
+		return
+	}
+	d.seq++
+	req := &dispatchRequest{task: t, scheduled: scheduled, catchUp: catchUp, queued: d.clock.Now(), seq: d.seq}
+	req.score = d.score(req)
+	heap.Push(&d.pending, req)
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_DispatchQueueDepth), float32(len(d.pending)))
+	d.cond.Signal()
+}
+
+// submitOutOfBand queues a run started with Scheduler.RunNow to be run by the next free worker. Return error if the
+// dispatcher has been stopped.
+func (d *dispatcher) submitOutOfBand(t *Task, instanceID string, opts *runOptions) error {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	if d.stopped {
+		return joberrors.ErrorSchedulerShutdown{Message: "scheduler has been shut down"}
+	}
+	d.seq++
+	req := &dispatchRequest{task: t, queued: d.clock.Now(), seq: d.seq, instanceID: instanceID, runOpts: opts}
+	req.score = d.score(req)
+	heap.Push(&d.pending, req)
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_DispatchQueueDepth), float32(len(d.pending)))
+	d.cond.Signal()
+	return nil
+}
+
+// remove drops any pending runs of the Task with the given id, returning them
+func (d *dispatcher) remove(id string) []*dispatchRequest {
+	return d.removeWhere(func(req *dispatchRequest) bool {
+		return req.task.id == id
+	})
+}
+
+// removeScheduled drops any pending scheduled runs of the Task with the given id, returning them. Runs started
+// with Scheduler.RunNow are left queued
+func (d *dispatcher) removeScheduled(id string) []*dispatchRequest {
+	return d.removeWhere(func(req *dispatchRequest) bool {
+		return req.task.id == id && req.runOpts == nil
+	})
+}
+
+func (d *dispatcher) removeWhere(match func(req *dispatchRequest) bool) []*dispatchRequest {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	var removed []*dispatchRequest
+	pending := d.pending[:0]
+	for _, req := range d.pending {
+		if !match(req) {
+			pending = append(pending, req)
+		} else {
+			removed = append(removed, req)
+		}
+	}
+	for i := len(pending); i < len(d.pending); i++ {
+		d.pending[i] = nil
+	}
+	d.pending = pending
+	heap.Init(&d.pending)
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_DispatchQueueDepth), float32(len(d.pending)))
+	return removed
+}
+
+// clear drops all pending runs
+func (d *dispatcher) clear() {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.pending = nil
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_DispatchQueueDepth), 0)
+}
+
+// stop drops all pending runs, and stops the workers once they finish their current run
+func (d *dispatcher) stop() {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	d.stopped = true
+	d.pending = nil
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_DispatchQueueDepth), 0)
+	d.cond.Broadcast()
+}
+
+func (d *dispatcher) next() *dispatchRequest {
+	d.mx.Lock()
+	defer d.mx.Unlock()
+	for len(d.pending) == 0 && !d.stopped {
+		d.cond.Wait()
+	}
+	if d.stopped {
+		return nil
+	}
+	req := heap.Pop(&d.pending).(*dispatchRequest)
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_DispatchQueueDepth), float32(len(d.pending)))
+	return req
+}
+
+// score is the effective priority of a pending run: the priority of its Task plus one for every aging period it
+// has waited. As every pending run ages at the same rate, the order only depends on when each run was queued, so
+// the score can be calculated once when the run is submitted:
+//	priority + (now - queued) / aging  orders the same as  priority - queued / aging
+func (d *dispatcher) score(req *dispatchRequest) float64 {
+	score := float64(req.task.GetPriority())
+	if d.aging > 0 {
+		score -= float64(req.queued.UnixNano()) / float64(d.aging)
+	}
+	return score
+}
+
+func (d *dispatcher) worker() {
+	for {
+		req := d.next()
+		if req == nil {
+			return
+		}
+		waited := d.clock.Now().Sub(req.queued)
+		labels := []metrics.Label{{Name: "id", Value: req.task.id}}
+		metrics.AddSampleWithLabels(schedmetrics.GetMetricsSummaryKey(schedmetrics.Metrics_Summary_DispatchWait), float32(waited.Seconds()), labels)
+		if req.runOpts != nil {
+			// Runs started with RunNow were asked for explicitly, so are never stale
+			req.task.runOutOfBand(req.instanceID, req.runOpts)
+			continue
+		}
+		if d.maxWait > 0 && waited > d.maxWait {
+			d.log.Info("Run Waited Too Long for a Worker", "jobid", req.task.id, "waited", waited, "policy", d.stalePolicy)
+			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_StaleDispatches), 1, labels)
+			switch d.stalePolicy {
+			case StaleDispatch_Defer:
+				req.task.deferRun(req.scheduled, joberrors.FailedJobError{Message: "run waited too long for a worker", ErrorType: joberrors.Error_DeferedJob}, !req.catchUp)
+			default:
+				req.task.emit(Event{Type: EventType_Dropped, Scheduled: req.scheduled})
+				if !req.catchUp {
+					req.task.reschedule()
+				}
+			}
+			continue
+		}
+		req.task.runScheduled(req.scheduled, req.catchUp)
+	}
+}
+
+type dispatchQueue []*dispatchRequest
+
+func (q dispatchQueue) Len() int {
+	return len(q)
+}
+
+func (q dispatchQueue) Less(i, j int) bool {
+	if q[i].score != q[j].score {
+		return q[i].score > q[j].score
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q dispatchQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *dispatchQueue) Push(x interface{}) {
+	*q = append(*q, x.(*dispatchRequest))
+}
+
+func (q *dispatchQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
+}
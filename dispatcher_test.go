@@ -0,0 +1,54 @@
+package taskmanager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/go-logr/logr"
+)
+
+func newPriorityTestTask(fc *clock.Fake, id string, priority int) *Task {
+	timer, _ := NewOnce(0)
+	return NewSchedule(context.Background(), id, timer, func(context.Context) {}, WithLogger(logr.Discard()), WithClock(fc), WithPriority(priority))
+}
+
+func TestDispatcherPriority(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	d := newDispatcher(0, 0, StaleDispatch_Drop, 0, fc, logr.Discard())
+	d.submit(newPriorityTestTask(fc, "low", 0), fc.Now(), false)
+	d.submit(newPriorityTestTask(fc, "high", 10), fc.Now(), false)
+	d.submit(newPriorityTestTask(fc, "low2", 0), fc.Now(), false)
+	d.submit(newPriorityTestTask(fc, "mid", 5), fc.Now(), false)
+	for _, want := range []string{"high", "mid", "low", "low2"} {
+		if got := d.next().task.id; got != want {
+			t.Errorf("Expected %s to be dispatched, got %s", want, got)
+		}
+	}
+}
+
+func TestDispatcherPriorityAging(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	d := newDispatcher(0, 0, StaleDispatch_Drop, 10*time.Second, fc, logr.Discard())
+	d.submit(newPriorityTestTask(fc, "low", 0), fc.Now(), false)
+	fc.Advance(25 * time.Second)
+	d.submit(newPriorityTestTask(fc, "high", 2), fc.Now(), false)
+	d.submit(newPriorityTestTask(fc, "higher", 3), fc.Now(), false)
+	// low has aged by 2.5 while waiting, so it is ahead of high but not higher
+	for _, want := range []string{"higher", "low", "high"} {
+		if got := d.next().task.id; got != want {
+			t.Errorf("Expected %s to be dispatched, got %s", want, got)
+		}
+	}
+}
+
+func TestRunQueuePriority(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	rq := newRunQueue()
+	rq.push(newPriorityTestTask(fc, "a", 0))
+	rq.push(newPriorityTestTask(fc, "b", 1))
+	if got := rq.peek().id; got != "b" {
+		t.Errorf("Expected b to be due first, got %s", got)
+	}
+}
@@ -0,0 +1,250 @@
+package taskmanager
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/job"
+	schedmetrics "github.com/Fishwaldo/go-taskmanager/metrics"
+	"github.com/armon/go-metrics"
+)
+
+//EventType The type of a lifecycle Event
+type EventType int
+
+const (
+	// EventType_Added Task was added to the Scheduler
+	EventType_Added EventType = iota
+	// EventType_Removed Task was removed from the Scheduler
+	EventType_Removed
+	// EventType_Started Task was Started
+	EventType_Started
+	// EventType_Stopped Task was Stopped
+	EventType_Stopped
+	// EventType_Paused Task was Paused
+	EventType_Paused
+	// EventType_Resumed Task was Resumed
+	EventType_Resumed
+	// EventType_Dispatched A scheduled run of the Task is due and was dispatched
+	EventType_Dispatched
+	// EventType_Dropped A scheduled run waited too long for a worker and was dropped
+	EventType_Dropped
+	// EventType_RunStarted A Job instance started running
+	EventType_RunStarted
+	// EventType_RunFinished A Job instance finished, State holds its final job.State
+	EventType_RunFinished
+	// EventType_RunCanceled A Pre Execution Middleware canceled a run
+	EventType_RunCanceled
+	// EventType_Deferred A run was deferred to the Retry Middleware without running the Job
+	EventType_Deferred
+	// EventType_RetryScheduled A Retry Middleware rescheduled the Task, after Delay
+	EventType_RetryScheduled
+	// EventType_RetriesExhausted A Retry Middleware stopped retrying the Task
+	EventType_RetriesExhausted
+	// EventType_Updated The Job, Timer or Options of the Task were replaced, see Scheduler.UpdateJob
+	EventType_Updated
+	// EventType_Skipped A scheduled run was not dispatched, as it could not be claimed with the Locker, see WithLocker
+	EventType_Skipped
+	// EventType_Misfired A run was dispatched more than the misfire threshold after it was scheduled, see WithMisfirePolicy
+	EventType_Misfired
+)
+
+func (e EventType) String() string {
+	switch e {
+	case EventType_Added:
+		return "ADDED"
+	case EventType_Removed:
+		return "REMOVED"
+	case EventType_Started:
+		return "STARTED"
+	case EventType_Stopped:
+		return "STOPPED"
+	case EventType_Paused:
+		return "PAUSED"
+	case EventType_Resumed:
+		return "RESUMED"
+	case EventType_Dispatched:
+		return "DISPATCHED"
+	case EventType_Dropped:
+		return "DROPPED"
+	case EventType_RunStarted:
+		return "RUNSTARTED"
+	case EventType_RunFinished:
+		return "RUNFINISHED"
+	case EventType_RunCanceled:
+		return "RUNCANCELED"
+	case EventType_Deferred:
+		return "DEFERRED"
+	case EventType_RetryScheduled:
+		return "RETRYSCHEDULED"
+	case EventType_RetriesExhausted:
+		return "RETRIESEXHAUSTED"
+	case EventType_Updated:
+		return "UPDATED"
+	case EventType_Skipped:
+		return "SKIPPED"
+	case EventType_Misfired:
+		return "MISFIRED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+//Event A lifecycle Event of a Task, see Scheduler.Subscribe
+type Event struct {
+	Type   EventType
+	TaskID string
+	// Time the Event happened, according to the Clock of the Task
+	Time time.Time
+	// ID of the Job instance, for Events about a run
+	InstanceID string
+	// Time the run was scheduled for, for Dispatched, Dropped, Deferred and Skipped Events
+	Scheduled time.Time
+	// Final job.State of the run, for RunFinished Events
+	State job.State
+	// Error of the run, or returned by Middleware, if any
+	Error string
+	// Type name of the Middleware that took the decision, for RunCanceled, RetryScheduled and RetriesExhausted Events
+	Middleware string
+	// Delay requested by the Retry Middleware, for RetryScheduled Events
+	Delay time.Duration
+	// Number of runs missed, and of those that are dispatched according to the MisfirePolicy, for Misfired Events
+	Missed int
+	Runs   int
+}
+
+//EventFilter Selects the Events delivered to a subscriber, a nil EventFilter selects every Event
+type EventFilter func(e Event) bool
+
+//EventTypes Returns a EventFilter that selects Events of the given Types
+func EventTypes(types ...EventType) EventFilter {
+	return func(e Event) bool {
+		for _, t := range types {
+			if e.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+//EventTaskIDs Returns a EventFilter that selects Events of the Tasks with the given ids
+func EventTaskIDs(ids ...string) EventFilter {
+	return func(e Event) bool {
+		for _, id := range ids {
+			if e.TaskID == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// eventBufferSize is the number of Events buffered for each subscriber before Events are dropped
+const eventBufferSize = 100
+
+type subscription struct {
+	filter EventFilter
+	events chan Event
+}
+
+// eventBus delivers Events to subscribers without ever blocking the publisher
+type eventBus struct {
+	mx     sync.RWMutex
+	subs   map[<-chan Event]*subscription
+	closed bool
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{
+		subs: make(map[<-chan Event]*subscription),
+	}
+}
+
+func (b *eventBus) subscribe(filter EventFilter) <-chan Event {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	sub := &subscription{filter: filter, events: make(chan Event, eventBufferSize)}
+	if b.closed {
+		close(sub.events)
+		return sub.events
+	}
+	b.subs[sub.events] = sub
+	return sub.events
+}
+
+func (b *eventBus) unsubscribe(events <-chan Event) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	sub, ok := b.subs[events]
+	if !ok {
+		return false
+	}
+	delete(b.subs, events)
+	close(sub.events)
+	return true
+}
+
+// publish delivers e to every subscriber whose filter selects it, dropping it for subscribers that are full
+func (b *eventBus) publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+	for _, sub := range b.subs {
+		if sub.filter != nil && !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_DroppedEvents), 1, []metrics.Label{{Name: "id", Value: e.TaskID}, {Name: "type", Value: e.Type.String()}})
+		}
+	}
+}
+
+// close closes every subscriber's channel, Events published afterwards are discarded
+func (b *eventBus) close() {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for events, sub := range b.subs {
+		delete(b.subs, events)
+		close(sub.events)
+	}
+}
+
+//Subscribe Returns a channel that receives the lifecycle Events of every Task in the Scheduler selected by filter.
+//Events are never waited for: if a subscriber falls more than 100 Events behind, further Events are dropped for it
+//(and counted in the sched.droppedevents metric) until it catches up. The channel is closed by Unsubscribe, or
+//once Shutdown returns.
+func (s *Scheduler) Subscribe(filter EventFilter) <-chan Event {
+	return s.events.subscribe(filter)
+}
+
+//Unsubscribe Stop delivering Events to, and close, a channel returned by Subscribe. Return false if it is not
+//subscribed.
+func (s *Scheduler) Unsubscribe(events <-chan Event) bool {
+	return s.events.unsubscribe(events)
+}
+
+// emit publishes a Event about the Scheduler's Task with the given id
+func (s *Scheduler) emit(id string, e Event) {
+	e.TaskID = id
+	e.Time = s.clock.Now()
+	s.events.publish(e)
+}
+
+// emit publishes a Event about the Task, if it has been added to a Scheduler
+func (s *Task) emit(e Event) {
+	if s.events == nil {
+		return
+	}
+	e.TaskID = s.id
+	e.Time = s.clock.Now()
+	s.events.publish(e)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/admin"
 	"github.com/Fishwaldo/go-taskmanager/job"
 	prometheusConfig "github.com/Fishwaldo/go-taskmanager/metrics/prometheus"
 	executionmiddleware "github.com/Fishwaldo/go-taskmanager/middleware/executation"
@@ -113,6 +114,11 @@ func main() {
 		taskmanager.WithLogger(log.WithName("scheduler")),
 	)
 
+	// Serve the admin API next to the metrics
+	adminHandler := admin.NewHandler(scheduler)
+	adminHandler.AddTagHandler("hello", thmw)
+	http.Handle("/admin/", http.StripPrefix("/admin", adminHandler))
+
 	//ctx1, cancel1 := context.WithCancel(context.Background())
 	ctx2, cancel2 := context.WithCancel(context.Background())
 
@@ -138,7 +144,11 @@ func main() {
 	//cancel1()
 	cancel2()
 
-	// Stop before shutting down.
-	scheduler.StopAll()
+	// Stop before shutting down, giving running jobs 30 seconds to finish.
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	if err := scheduler.Shutdown(shutdownCtx); err != nil {
+		log.Error(err, "Scheduler Shutdown Incomplete")
+	}
 
 }
@@ -0,0 +1,142 @@
+package taskmanager
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/job"
+)
+
+//MiddlewareStage The stage of a run a Middleware decision was taken in
+type MiddlewareStage int
+
+const (
+	// MiddlewareStage_PreExecution The PreHandler of a Execution Middleware, before the Job runs
+	MiddlewareStage_PreExecution MiddlewareStage = iota
+	// MiddlewareStage_PostExecution The PostHandler of a Execution Middleware, after the Job ran
+	MiddlewareStage_PostExecution
+	// MiddlewareStage_Retry The Handler of a Retry Middleware
+	MiddlewareStage_Retry
+)
+
+func (m MiddlewareStage) String() string {
+	switch m {
+	case MiddlewareStage_PreExecution:
+		return "PREEXECUTION"
+	case MiddlewareStage_PostExecution:
+		return "POSTEXECUTION"
+	case MiddlewareStage_Retry:
+		return "RETRY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+//MarshalText Encodes the MiddlewareStage as its String
+func (m MiddlewareStage) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+//UnmarshalText Decodes a MiddlewareStage encoded with MarshalText
+func (m *MiddlewareStage) UnmarshalText(text []byte) error {
+	for v := MiddlewareStage_PreExecution; v <= MiddlewareStage_Retry; v++ {
+		if v.String() == string(text) {
+			*m = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown middleware stage %q", string(text))
+}
+
+//MiddlewareDecision The result a Middleware returned during a run
+type MiddlewareDecision struct {
+	Stage MiddlewareStage
+	// Type name of the Middleware
+	Middleware string
+	// MWResult_Op or RetryResult_Op returned by the Middleware
+	Result string
+	// Delay requested by a Retry Middleware
+	Delay time.Duration
+	// Error returned by the Middleware, if any
+	Error string
+}
+
+//RunRecord A past run of a Task, kept in its History. Runs canceled or deferred by Middleware never start the Job,
+//so have a zero Start and Finish and a job.NEW State.
+type RunRecord struct {
+	InstanceID string
+	// Time the run was scheduled for, zero if it was started with RunNow
+	Scheduled time.Time
+	// Run was started with RunNow
+	OutOfBand bool
+	Start     time.Time
+	Finish    time.Time
+	State     job.State
+	// Empty if the run did not fail
+	Error     string
+	Decisions []MiddlewareDecision
+}
+
+func (r *RunRecord) decide(stage MiddlewareStage, middleware interface{}, result string, delay time.Duration, err error) {
+	decision := MiddlewareDecision{
+		Stage:      stage,
+		Middleware: fmt.Sprintf("%T", middleware),
+		Result:     result,
+		Delay:      delay,
+	}
+	if err != nil {
+		decision.Error = err.Error()
+	}
+	r.Decisions = append(r.Decisions, decision)
+}
+
+// runHistory is a ring buffer of the last RunRecords of a Task
+type runHistory struct {
+	mx      sync.Mutex
+	records []RunRecord
+	next    int
+	size    int
+}
+
+func newRunHistory(size int) *runHistory {
+	if size < 0 {
+		size = 0
+	}
+	return &runHistory{
+		records: make([]RunRecord, 0, size),
+		size:    size,
+	}
+}
+
+// capacity returns the number of runs kept
+func (h *runHistory) capacity() int {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return h.size
+}
+
+func (h *runHistory) add(rec *RunRecord) {
+	if h.size == 0 {
+		return
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if len(h.records) < h.size {
+		h.records = append(h.records, *rec)
+	} else {
+		h.records[h.next] = *rec
+	}
+	h.next = (h.next + 1) % h.size
+}
+
+// list returns a copy of the records, newest first
+func (h *runHistory) list() []RunRecord {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	records := make([]RunRecord, 0, len(h.records))
+	for i := 1; i <= len(h.records); i++ {
+		records = append(records, h.records[(h.next-i+len(h.records))%len(h.records)])
+	}
+	return records
+}
@@ -0,0 +1,207 @@
+package taskmanager
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
+)
+
+//TaskBinder is an optional Interface a Timer can implement to learn the ID of the Task it is added to, before its
+//first run is calculated, such as a Cron with H in its expression.
+type TaskBinder interface {
+	BindTask(id string)
+}
+
+type jitterOption struct {
+	max time.Duration
+}
+
+func (j jitterOption) apply(opts *taskoptions) {
+	opts.jitter = j.max
+}
+
+func (j jitterOption) taskDefault() {}
+
+//WithJitter Delay every run of the Task by a offset between 0 and max, so Tasks with the same Timer do not all run at
+//once. The offset is worked out by hashing the ID of the Task, so a Task always runs at the same offset. Works with any
+//Timer. Timers that fire relative to when they are started, Fixed and Once created with NewOnce, only have their
+//first run delayed, which shifts every run after it.
+func WithJitter(max time.Duration) Option {
+	return jitterOption{max: max}
+}
+
+// hashSeed returns the hash of seed, and of part if given, used to spread Tasks
+func hashSeed(seed string, part int) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(seed))
+	if part >= 0 {
+		_, _ = h.Write([]byte{0, byte(part)})
+	}
+	return h.Sum64()
+}
+
+// bindTimer binds timer to the Task id, and wraps it to apply jitter
+func bindTimer(timer Timer, id string, jitter time.Duration) Timer {
+	if tb, ok := timer.(TaskBinder); ok {
+		tb.BindTask(id)
+	}
+	if jitter <= 0 {
+		return timer
+	}
+	j := &jitterTimer{timer: timer, offset: time.Duration(hashSeed(id, -1) % uint64(jitter))}
+	switch t := timer.(type) {
+	case *Fixed:
+		j.relative = true
+	case *Once:
+		j.relative = t.at.IsZero()
+	}
+	return j
+}
+
+// jitterTimer delays the runs of timer by offset, see WithJitter. A relative timer only has its first run delayed,
+// otherwise timer is given a Clock that is offset behind, so it fires offset after the times it would.
+type jitterTimer struct {
+	timer    Timer
+	offset   time.Duration
+	relative bool
+	shifted  bool
+}
+
+func (j *jitterTimer) Next() (time.Time, bool) {
+	next, done := j.timer.Next()
+	if done || (j.relative && j.shifted) {
+		return next, done
+	}
+	j.shifted = true
+	return next.Add(j.offset), false
+}
+
+func (j *jitterTimer) Reschedule(d time.Duration) {
+	j.timer.Reschedule(d)
+}
+
+// Spec returns the Spec of timer, as the jitter is a Option of the Task rather than part of the Timer
+func (j *jitterTimer) Spec() string {
+	return timerSpec(j.timer)
+}
+
+func (j *jitterTimer) SetClock(c clock.Clock) {
+	if cs, ok := j.timer.(ClockSetter); ok {
+		if j.relative {
+			cs.SetClock(c)
+			return
+		}
+		cs.SetClock(offsetClock{Clock: c, offset: j.offset})
+	}
+}
+
+func (j *jitterTimer) NextAfter(t time.Time) (time.Time, bool) {
+	fc, ok := j.timer.(Forecaster)
+	if !ok {
+		return time.Time{}, true
+	}
+	if j.relative {
+		return fc.NextAfter(t)
+	}
+	next, done := fc.NextAfter(t.Add(-j.offset))
+	if done {
+		return next, done
+	}
+	return next.Add(j.offset), false
+}
+
+// offsetClock is a Clock that is offset behind Clock
+type offsetClock struct {
+	clock.Clock
+	offset time.Duration
+}
+
+func (o offsetClock) Now() time.Time {
+	return o.Clock.Now().Add(-o.offset)
+}
+
+// cronHashRanges are the values H can take in each field of a cron expression, from seconds to day of week. Day of
+// month is limited to 28, so it is valid in every month.
+var cronHashRanges = [6][2]int{{0, 59}, {0, 59}, {0, 23}, {1, 28}, {1, 12}, {0, 6}}
+
+// expandCronHash replaces every H in the fields of the cron expression expr with a value spread by hashing seed,
+// returning whether there were any:
+//
+//	H        a value in the range of the field
+//	H(a-b)   a value between a and b
+//	H/n      every n, starting at a value between the start of the range and n
+//	H(a-b)/n every n between a and b, starting at a value between a and a+n
+func expandCronHash(expr string, seed string) (string, bool, error) {
+	fields := strings.Fields(expr)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "@") {
+		return expr, false, nil
+	}
+	// Fields before the range of the first field of expr, which has a year field unless it has 5 fields
+	first := 1
+	if len(fields) == 7 {
+		first = 0
+	}
+	hashed := false
+	for i, field := range fields {
+		elems := strings.Split(field, ",")
+		for j, elem := range elems {
+			if elem != "H" && !strings.HasPrefix(elem, "H(") && !strings.HasPrefix(elem, "H/") {
+				continue
+			}
+			r := first + i
+			if r >= len(cronHashRanges) {
+				return "", false, fmt.Errorf("H is not supported in field %d of %q", i+1, expr)
+			}
+			v, err := expandCronHashElem(elem, cronHashRanges[r], hashSeed(seed, r))
+			if err != nil {
+				return "", false, fmt.Errorf("field %d of %q: %w", i+1, expr, err)
+			}
+			elems[j] = v
+			hashed = true
+		}
+		fields[i] = strings.Join(elems, ",")
+	}
+	return strings.Join(fields, " "), hashed, nil
+}
+
+func expandCronHashElem(elem string, r [2]int, h uint64) (string, error) {
+	lo, hi := r[0], r[1]
+	rest := elem[1:]
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return "", fmt.Errorf("invalid %q, expected H(a-b)", elem)
+		}
+		bounds := strings.SplitN(rest[1:end], "-", 2)
+		if len(bounds) != 2 {
+			return "", fmt.Errorf("invalid %q, expected H(a-b)", elem)
+		}
+		a, errA := strconv.Atoi(bounds[0])
+		b, errB := strconv.Atoi(bounds[1])
+		if errA != nil || errB != nil || a < lo || b > hi || a > b {
+			return "", fmt.Errorf("invalid range in %q, must be within %d-%d", elem, lo, hi)
+		}
+		lo, hi = a, b
+		rest = rest[end+1:]
+	}
+	switch {
+	case rest == "":
+		return strconv.Itoa(lo + int(h%uint64(hi-lo+1))), nil
+	case strings.HasPrefix(rest, "/"):
+		n, err := strconv.Atoi(rest[1:])
+		if err != nil || n < 1 {
+			return "", fmt.Errorf("invalid step in %q", elem)
+		}
+		span := n
+		if span > hi-lo+1 {
+			span = hi - lo + 1
+		}
+		return fmt.Sprintf("%d-%d/%d", lo+int(h%uint64(span)), hi, n), nil
+	default:
+		return "", fmt.Errorf("invalid %q", elem)
+	}
+}
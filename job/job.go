@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Fishwaldo/go-taskmanager/clock"
 	"github.com/Fishwaldo/go-taskmanager/joberrors"
 	"github.com/google/uuid"
 )
@@ -13,19 +15,73 @@ import (
 //Job Wraps JobFun and provide:
 //	1. Creation, Start, and Finish Time
 //	2. Recover From Panics
+//	3. Errors Returned by the JobFunc
 type Job struct {
 	id         string
-	jobFunc    func(ctx context.Context)
+	jobFunc    func(ctx context.Context) error
 	createTime time.Time
 	startTime  time.Time
 	finishTime time.Time
 	state      State
 	mx         sync.RWMutex
 	ctx        context.Context
+	clock      clock.Clock
+	timeout    time.Duration
+	hardKill   time.Duration
+	cancel     context.CancelFunc
+	tctx       *timeoutCtx
+	cancelled  bool
 }
 
 type JobCtxValue struct{}
 
+// Option to customize a Job, check the job.With*() functions that implement Option interface for the
+// available options
+type Option interface {
+	apply(*Job)
+}
+
+type clockOption struct {
+	clock clock.Clock
+}
+
+func (c clockOption) apply(j *Job) {
+	j.clock = c.clock
+}
+
+//WithClock Use the supplied Clock to record the Creation, Start and Finish Times of the Job
+func WithClock(c clock.Clock) Option {
+	return clockOption{clock: c}
+}
+
+type timeoutOption struct {
+	timeout time.Duration
+}
+
+func (t timeoutOption) apply(j *Job) {
+	j.timeout = t.timeout
+}
+
+//WithTimeout Cancel the Context passed to the JobFunc once it has run for timeout. A Job that times out finishes
+//in the TIMEDOUT State with a joberrors.Error_Timeout error, even if the JobFunc returns without error.
+func WithTimeout(timeout time.Duration) Option {
+	return timeoutOption{timeout: timeout}
+}
+
+type hardKillOption struct {
+	grace time.Duration
+}
+
+func (h hardKillOption) apply(j *Job) {
+	j.hardKill = h.grace
+}
+
+//WithHardKill Used with WithTimeout, stop waiting for a JobFunc that has not returned grace after its Context was
+//canceled. The JobFunc is abandoned (Go can not kill it) and the Job finishes in the ABANDONED State.
+func WithHardKill(grace time.Duration) Option {
+	return hardKillOption{grace: grace}
+}
+
 //State Return Job current state.
 func (j *Job) State() State {
 	j.mx.RLock()
@@ -34,21 +90,39 @@ func (j *Job) State() State {
 }
 
 //NewJobWithID Create new Job with the supplied Id.
-func NewJobWithID(ctx context.Context, id string, jobFunc func(context.Context)) *Job {
-	return &Job{
+func NewJobWithID(ctx context.Context, id string, jobFunc func(context.Context), opts ...Option) *Job {
+	return NewErrorJobWithID(ctx, id, func(ctx context.Context) error {
+		jobFunc(ctx)
+		return nil
+	}, opts...)
+}
+
+//NewJob Create new Job, id is assigned a UUID instead.
+func NewJob(ctx context.Context, jobFunc func(context.Context), opts ...Option) *Job {
+	return NewJobWithID(ctx, uuid.New().String(), jobFunc, opts...)
+}
+
+//NewErrorJobWithID Create new Job with the supplied Id from a JobFunc that returns an error.
+func NewErrorJobWithID(ctx context.Context, id string, jobFunc func(context.Context) error, opts ...Option) *Job {
+	j := &Job{
 		id:         id,
 		jobFunc:    jobFunc,
-		createTime: time.Now(),
 		startTime:  time.Time{},
 		finishTime: time.Time{},
 		state:      NEW,
 		ctx:        ctx,
+		clock:      clock.New(),
 	}
+	for _, opt := range opts {
+		opt.apply(j)
+	}
+	j.createTime = j.clock.Now()
+	return j
 }
 
-//NewJob Create new Job, id is assigned a UUID instead.
-func NewJob(ctx context.Context, jobFunc func(context.Context)) *Job {
-	return NewJobWithID(ctx, uuid.New().String(), jobFunc)
+//NewErrorJob Create new Job from a JobFunc that returns an error, id is assigned a UUID instead.
+func NewErrorJob(ctx context.Context, jobFunc func(context.Context) error, opts ...Option) *Job {
+	return NewErrorJobWithID(ctx, uuid.New().String(), jobFunc, opts...)
 }
 
 //ID Return Job ID
@@ -64,7 +138,7 @@ func (j *Job) ActualElapsed() time.Duration {
 
 	if !j.startTime.IsZero() {
 		if j.finishTime.IsZero() {
-			return time.Since(j.startTime)
+			return j.clock.Now().Sub(j.startTime)
 		}
 		return j.finishTime.Sub(j.startTime)
 	}
@@ -79,13 +153,35 @@ func (j *Job) TotalElapsed() time.Duration {
 
 	if !j.startTime.IsZero() {
 		if j.finishTime.IsZero() {
-			return time.Since(j.createTime)
+			return j.clock.Now().Sub(j.createTime)
 		}
 		return j.finishTime.Sub(j.createTime)
 	}
 	return -1
 }
 
+//Cancel Cancel the Context passed to the JobFunc. The Job finishes in the CANCELLED State with a
+//joberrors.Error_Cancelled error once the JobFunc returns. A Job that has not started yet is cancelled without
+//running. Returns false if the Job has already finished, or timed out.
+func (j *Job) Cancel() bool {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	switch j.state {
+	case NEW:
+		j.cancelled = true
+		return true
+	case RUNNING:
+		if j.cancelled || (j.tctx != nil && j.tctx.Err() == context.DeadlineExceeded) {
+			return false
+		}
+		j.cancelled = true
+		j.cancel()
+		return true
+	default:
+		return false
+	}
+}
+
 //Run Run the internal Job (synchronous)
 func (j *Job) Run() error {
 	return j.run()
@@ -103,28 +199,121 @@ func (j *Job) run() (err error) {
 		return err
 	}
 
-	// Handle Panics and set correct state
-	defer func() {
-		j.mx.Lock()
-		// TODO handle panics
-		if r := recover(); r != nil {
-			err = joberrors.FailedJobError{ErrorType: joberrors.Error_Panic, Message: fmt.Sprintf("job panicked: %v", r)}
-			j.state = PANICKED
-		} else {
-			j.state = FINISHED
-		}
-		j.finishTime = time.Now()
+	if j.cancelled {
+		j.state = CANCELLED
+		j.startTime = j.clock.Now()
+		j.finishTime = j.startTime
 		j.mx.Unlock()
-	}()
+		return joberrors.FailedJobError{ErrorType: joberrors.Error_Cancelled, Message: "job cancelled before it started"}
+	}
 
 	j.state = RUNNING
-	j.startTime = time.Now()
+	j.startTime = j.clock.Now()
+
+	ctx, cancel := context.WithCancel(context.WithValue(j.ctx, JobCtxValue{}, j))
+	defer cancel()
+	j.cancel = cancel
+	var tctx *timeoutCtx
+	if j.timeout > 0 {
+		tctx = &timeoutCtx{Context: ctx, deadline: j.startTime.Add(j.timeout)}
+		ctx = tctx
+		j.tctx = tctx
+	}
 
 	// Unlock State
 	j.mx.Unlock()
 
-	// Run Job
-	j.jobFunc(context.WithValue(j.ctx, JobCtxValue{}, j))
+	// Run Job, Handling Panics
+	result := make(chan jobResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- jobResult{panicked: true, err: joberrors.FailedJobError{ErrorType: joberrors.Error_Panic, Message: fmt.Sprintf("job panicked: %v", r)}}
+			}
+		}()
+		if jerr := j.jobFunc(ctx); jerr != nil {
+			result <- jobResult{err: joberrors.FailedJobError{ErrorType: joberrors.Error_JobError, Message: fmt.Sprintf("job returned error: %v", jerr), Err: jerr}}
+			return
+		}
+		result <- jobResult{}
+	}()
+
+	var timeoutChan <-chan time.Time
+	if j.timeout > 0 {
+		timeoutChan = j.clock.After(j.timeout)
+	}
+	var res jobResult
+	timedOut, abandoned := false, false
+	select {
+	case res = <-result:
+	case <-timeoutChan:
+		timedOut = true
+		tctx.expire()
+		cancel()
+		if j.hardKill > 0 {
+			select {
+			case res = <-result:
+			case <-j.clock.After(j.hardKill):
+				abandoned = true
+			}
+		} else {
+			res = <-result
+		}
+	}
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	switch {
+	case abandoned:
+		err = joberrors.FailedJobError{ErrorType: joberrors.Error_Timeout, Message: fmt.Sprintf("job ignored cancellation %s after timing out after %s and was abandoned", j.hardKill, j.timeout)}
+		j.state = ABANDONED
+	case res.panicked:
+		err = res.err
+		j.state = PANICKED
+	case j.cancelled:
+		err = joberrors.FailedJobError{ErrorType: joberrors.Error_Cancelled, Message: "job cancelled", Err: res.err}
+		j.state = CANCELLED
+	case timedOut:
+		err = joberrors.FailedJobError{ErrorType: joberrors.Error_Timeout, Message: fmt.Sprintf("job timed out after %s", j.timeout), Err: res.err}
+		j.state = TIMEDOUT
+	case res.err != nil:
+		err = res.err
+		j.state = FAILED
+	default:
+		j.state = FINISHED
+	}
+	j.finishTime = j.clock.Now()
+	return err
+}
+
+type jobResult struct {
+	err      error
+	panicked bool
+}
+
+// timeoutCtx is the Context passed to a Job with a timeout. The timeout is enforced with the Job's Clock rather
+// than a wall clock timer, so timeoutCtx reports the deadline and context.DeadlineExceeded itself.
+type timeoutCtx struct {
+	context.Context
+	deadline time.Time
+	expired  int32
+}
+
+func (c *timeoutCtx) Deadline() (time.Time, bool) {
+	if parent, ok := c.Context.Deadline(); ok && parent.Before(c.deadline) {
+		return parent, true
+	}
+	return c.deadline, true
+}
+
+func (c *timeoutCtx) Err() error {
+	if atomic.LoadInt32(&c.expired) == 1 {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
 
-	return nil
+// expire marks the deadline as passed, must be called before the Context is canceled
+func (c *timeoutCtx) expire() {
+	atomic.StoreInt32(&c.expired, 1)
 }
@@ -0,0 +1,122 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/Fishwaldo/go-taskmanager/joberrors"
+)
+
+func TestJobFinished(t *testing.T) {
+	j := NewJob(context.Background(), func(ctx context.Context) {})
+	if err := j.Run(); err != nil {
+		t.Errorf("Job Returned Error %s", err.Error())
+	}
+	if j.State() != FINISHED {
+		t.Errorf("Job State is %s, not FINISHED", j.State())
+	}
+}
+
+func TestJobPanic(t *testing.T) {
+	j := NewJob(context.Background(), func(ctx context.Context) { panic("test") })
+	err := j.Run()
+	var jerr joberrors.FailedJobError
+	if !errors.As(err, &jerr) || jerr.ErrorType != joberrors.Error_Panic {
+		t.Errorf("Job Did Not Return a Error_Panic: %v", err)
+	}
+	if j.State() != PANICKED {
+		t.Errorf("Job State is %s, not PANICKED", j.State())
+	}
+}
+
+func TestJobError(t *testing.T) {
+	testErr := errors.New("test error")
+	j := NewErrorJob(context.Background(), func(ctx context.Context) error { return testErr })
+	err := j.Run()
+	var jerr joberrors.FailedJobError
+	if !errors.As(err, &jerr) || jerr.ErrorType != joberrors.Error_JobError {
+		t.Errorf("Job Did Not Return a Error_JobError: %v", err)
+	}
+	if !errors.Is(err, testErr) {
+		t.Errorf("Job Error does not wrap the returned error: %v", err)
+	}
+	if j.State() != FAILED {
+		t.Errorf("Job State is %s, not FAILED", j.State())
+	}
+	if err := j.Run(); err == nil {
+		t.Errorf("Job Ran Twice")
+	}
+}
+
+func TestJobTimeout(t *testing.T) {
+	clk := clock.NewFake(time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC))
+	j := NewJob(context.Background(), func(ctx context.Context) { <-ctx.Done() }, WithClock(clk), WithTimeout(time.Minute))
+	done := make(chan error)
+	go func() { done <- j.Run() }()
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	err := <-done
+	var jerr joberrors.FailedJobError
+	if !errors.As(err, &jerr) || jerr.ErrorType != joberrors.Error_Timeout {
+		t.Errorf("Job Did Not Return a Error_Timeout: %v", err)
+	}
+	if j.State() != TIMEDOUT {
+		t.Errorf("Job State is %s, not TIMEDOUT", j.State())
+	}
+}
+
+func TestJobHardKill(t *testing.T) {
+	clk := clock.NewFake(time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC))
+	release := make(chan struct{})
+	defer close(release)
+	j := NewJob(context.Background(), func(ctx context.Context) { <-release }, WithClock(clk), WithTimeout(time.Minute), WithHardKill(time.Second))
+	done := make(chan error)
+	go func() { done <- j.Run() }()
+	clk.BlockUntil(1)
+	clk.Advance(time.Minute)
+	clk.BlockUntil(1)
+	clk.Advance(time.Second)
+	err := <-done
+	var jerr joberrors.FailedJobError
+	if !errors.As(err, &jerr) || jerr.ErrorType != joberrors.Error_Timeout {
+		t.Errorf("Job Did Not Return a Error_Timeout: %v", err)
+	}
+	if j.State() != ABANDONED {
+		t.Errorf("Job State is %s, not ABANDONED", j.State())
+	}
+}
+
+func TestJobCancel(t *testing.T) {
+	started := make(chan struct{})
+	j := NewErrorJob(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	done := make(chan error)
+	go func() { done <- j.Run() }()
+	<-started
+	if !j.Cancel() {
+		t.Errorf("Cancel of a running Job returned false")
+	}
+	err := <-done
+	var jerr joberrors.FailedJobError
+	if !errors.As(err, &jerr) || jerr.ErrorType != joberrors.Error_Cancelled {
+		t.Errorf("Job Did Not Return a Error_Cancelled: %v", err)
+	}
+	if j.State() != CANCELLED {
+		t.Errorf("Job State is %s, not CANCELLED", j.State())
+	}
+	if j.Cancel() {
+		t.Errorf("Cancel of a finished Job returned true")
+	}
+
+	j = NewJob(context.Background(), func(ctx context.Context) { t.Errorf("Cancelled Job Ran") })
+	j.Cancel()
+	if err := j.Run(); !errors.As(err, &jerr) || jerr.ErrorType != joberrors.Error_Cancelled {
+		t.Errorf("Job Did Not Return a Error_Cancelled: %v", err)
+	}
+}
@@ -1,5 +1,7 @@
 package job
 
+import "fmt"
+
 //State Indicate the state of the Job
 type State int64
 
@@ -12,6 +14,15 @@ const (
 	FINISHED
 	// PANICKED Job started and finished but encountered a panic.
 	PANICKED
+	// FAILED Job started and finished but returned an error.
+	FAILED
+	// TIMEDOUT Job started and finished after running for longer than its timeout.
+	TIMEDOUT
+	// ABANDONED Job ran for longer than its timeout and ignored the cancellation of its Context, so is no
+	// longer waited for. It may still be running.
+	ABANDONED
+	// CANCELLED Job was cancelled with Cancel, before it started or while it was running.
+	CANCELLED
 )
 
 func (s State) String() string {
@@ -24,7 +35,31 @@ func (s State) String() string {
 		return "FINISHED"
 	case PANICKED:
 		return "PANICKED"
+	case FAILED:
+		return "FAILED"
+	case TIMEDOUT:
+		return "TIMEDOUT"
+	case ABANDONED:
+		return "ABANDONED"
+	case CANCELLED:
+		return "CANCELLED"
 	default:
 		return "UNKNOWN"
 	}
 }
+
+//MarshalText Encodes the State as its String
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+//UnmarshalText Decodes a State encoded with MarshalText
+func (s *State) UnmarshalText(text []byte) error {
+	for state := NEW; state <= CANCELLED; state++ {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown job state %q", string(text))
+}
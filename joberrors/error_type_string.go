@@ -12,11 +12,15 @@ func _() {
 	_ = x[Error_Panic-1]
 	_ = x[Error_ConcurrentJob-2]
 	_ = x[Error_DeferedJob-3]
+	_ = x[Error_Middleware-4]
+	_ = x[Error_JobError-5]
+	_ = x[Error_Timeout-6]
+	_ = x[Error_Cancelled-7]
 }
 
-const _Error_Type_name = "Error_NoneError_PanicError_ConcurrentJobError_DeferedJob"
+const _Error_Type_name = "Error_NoneError_PanicError_ConcurrentJobError_DeferedJobError_MiddlewareError_JobErrorError_TimeoutError_Cancelled"
 
-var _Error_Type_index = [...]uint8{0, 10, 21, 40, 56}
+var _Error_Type_index = [...]uint8{0, 10, 21, 40, 56, 72, 86, 99, 114}
 
 func (i Error_Type) String() string {
 	if i < 0 || i >= Error_Type(len(_Error_Type_index)-1) {
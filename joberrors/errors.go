@@ -2,6 +2,7 @@ package joberrors
 
 import (
 //	"errors"
+	"fmt"
 )
 
 //go:generate stringer -type=Error_Type
@@ -12,11 +13,16 @@ const (
 	Error_ConcurrentJob
 	Error_DeferedJob
 	Error_Middleware
+	Error_JobError
+	Error_Timeout
+	Error_Cancelled
 )
 
 type FailedJobError struct {
 	Message string
 	ErrorType Error_Type
+	// Err is the error returned by the Job, if any.
+	Err error
 }
 
 func (e FailedJobError) Error() string {
@@ -31,6 +37,11 @@ func (e FailedJobError) Is(target error) bool {
 	return true;
 }
 
+// Unwrap returns the error returned by the Job (if any) so it can be inspected with errors.Is and errors.As
+func (e FailedJobError) Unwrap() error {
+	return e.Err
+}
+
 //ErrorScheduleNotFound Error When we can't find a Schedule
 type ErrorScheduleNotFound struct {
 	Message string
@@ -40,6 +51,33 @@ func (e ErrorScheduleNotFound) Error() string {
 	return e.Message
 }
 
+//ErrorRunNotFound Error When we can't find a running Job instance of a Schedule
+type ErrorRunNotFound struct {
+	Message string
+}
+
+func (e ErrorRunNotFound) Error() string {
+	return e.Message
+}
+
+//ErrorJobTypeNotFound Error When a job type is not registered in a JobRegistry
+type ErrorJobTypeNotFound struct {
+	Message string
+}
+
+func (e ErrorJobTypeNotFound) Error() string {
+	return e.Message
+}
+
+//ErrorJobTypeExists Error When a job type is already registered in a JobRegistry
+type ErrorJobTypeExists struct {
+	Message string
+}
+
+func (e ErrorJobTypeExists) Error() string {
+	return e.Message
+}
+
 //ErrorScheduleExists Error When a schedule already exists
 type ErrorScheduleExists struct {
 	Message string
@@ -49,4 +87,28 @@ func (e ErrorScheduleExists) Error() string {
 	return e.Message
 }
 
+//ErrorSchedulerShutdown Error When the Scheduler has been shut down
+type ErrorSchedulerShutdown struct {
+	Message string
+}
 
+func (e ErrorSchedulerShutdown) Error() string {
+	return e.Message
+}
+
+//ErrorShutdownIncomplete Error When Jobs were still running when a Shutdown's Context expired
+type ErrorShutdownIncomplete struct {
+	Message string
+	// Running Job Instance IDs, keyed by the Schedule ID
+	Running map[string][]string
+	// Err is the error of the Context that expired
+	Err error
+}
+
+func (e ErrorShutdownIncomplete) Error() string {
+	return fmt.Sprintf("%s: %v", e.Message, e.Running)
+}
+
+func (e ErrorShutdownIncomplete) Unwrap() error {
+	return e.Err
+}
@@ -1,6 +1,7 @@
 package taskmanager
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/Fishwaldo/go-taskmanager/job"
@@ -34,3 +35,31 @@ func (jm *jobMap) len() int {
 	defer jm.mx.RUnlock()
 	return len(jm.jobs)
 }
+
+func (jm *jobMap) ids() []string {
+	jm.mx.RLock()
+	defer jm.mx.RUnlock()
+	ids := make([]string, 0, len(jm.jobs))
+	for id := range jm.jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (jm *jobMap) get(id string) (*job.Job, bool) {
+	jm.mx.RLock()
+	defer jm.mx.RUnlock()
+	j, ok := jm.jobs[id]
+	return j, ok
+}
+
+func (jm *jobMap) all() []*job.Job {
+	jm.mx.RLock()
+	defer jm.mx.RUnlock()
+	jobs := make([]*job.Job, 0, len(jm.jobs))
+	for _, j := range jm.jobs {
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
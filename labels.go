@@ -0,0 +1,47 @@
+package taskmanager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//LabelSelector Selects Tasks by their Labels (see WithLabels). A Task matches if it has every label in the
+//selector with the same value. An empty LabelSelector matches every Task.
+type LabelSelector map[string]string
+
+//ParseLabelSelector Parses a selector in the form "key=value,key2=value2"
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	sel := make(LabelSelector)
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		kv := strings.SplitN(term, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid label selector term %q, must be key=value", term)
+		}
+		sel[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return sel, nil
+}
+
+//Matches Returns true if labels has every label in the selector
+func (sel LabelSelector) Matches(labels map[string]string) bool {
+	for k, v := range sel {
+		if lv, ok := labels[k]; !ok || lv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (sel LabelSelector) String() string {
+	terms := make([]string, 0, len(sel))
+	for k, v := range sel {
+		terms = append(terms, k+"="+v)
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, ",")
+}
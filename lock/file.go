@@ -0,0 +1,102 @@
+// Package lock provides Lockers, so that only one of several Schedulers with the same Tasks dispatches each
+// scheduled run, see taskmanager.Locker.
+package lock
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+)
+
+var _ taskmanager.Locker = (*File)(nil)
+
+//File A Locker for Schedulers on the same host, that records the claimed runs in a ledger file, which is locked with
+//flock (LockFileEx on Windows) while it is read and updated. Claims are forgotten after the retention given to
+//NewFile, which must be longer than the clocks of the Schedulers can disagree by.
+type File struct {
+	mx        sync.Mutex
+	path      string
+	retention time.Duration
+}
+
+//NewFile Returns a File Locker with the ledger file at path, which is created if it does not exist
+func NewFile(path string, retention time.Duration) (*File, error) {
+	if retention <= 0 {
+		return nil, fmt.Errorf("invalid retention, must be > 0")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &File{path: path, retention: retention}, nil
+}
+
+//TryLock Claim the run identified by key. Returns false if it is already in the ledger.
+func (l *File) TryLock(key string) (bool, error) {
+	if strings.ContainsAny(key, "\t\n") {
+		return false, fmt.Errorf("invalid lock key %q", key)
+	}
+	l.mx.Lock()
+	defer l.mx.Unlock()
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if err := lockFile(f); err != nil {
+		return false, fmt.Errorf("locking %s: %w", l.path, err)
+	}
+	defer unlockFile(f)
+
+	// Each line of the ledger is a claimed key and when the claim expires, in Unix nanoseconds
+	now := time.Now()
+	var keep []string
+	claimed := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		expires, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || time.Unix(0, expires).Before(now) {
+			continue
+		}
+		if fields[0] == key {
+			claimed = true
+		}
+		keep = append(keep, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("reading %s: %w", l.path, err)
+	}
+	if claimed {
+		return false, nil
+	}
+	keep = append(keep, key+"\t"+strconv.FormatInt(now.Add(l.retention).UnixNano(), 10))
+
+	// Rewrite the ledger without the expired claims
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	if err := f.Truncate(0); err != nil {
+		return false, err
+	}
+	if _, err := f.WriteString(strings.Join(keep, "\n") + "\n"); err != nil {
+		return false, err
+	}
+	if err := f.Sync(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package lock
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/go-logr/logr"
+)
+
+var testTime = time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+// testLockers claims the same keys with two Lockers standing for two Schedulers
+func testLockers(t *testing.T, a, b taskmanager.Locker) {
+	key := taskmanager.LockKey("export", testTime)
+	if claimed, err := a.TryLock(key); err != nil || !claimed {
+		t.Fatalf("First TryLock Returned %t, %v", claimed, err)
+	}
+	if claimed, err := b.TryLock(key); err != nil || claimed {
+		t.Errorf("TryLock of a claimed key Returned %t, %v", claimed, err)
+	}
+	if claimed, err := b.TryLock(taskmanager.LockKey("export", testTime.Add(1*time.Hour))); err != nil || !claimed {
+		t.Errorf("TryLock of the next run Returned %t, %v", claimed, err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks")
+	a, err := NewFile(path, 1*time.Hour)
+	if err != nil {
+		t.Fatalf("NewFile Returned Error: %s", err.Error())
+	}
+	b, _ := NewFile(path, 1*time.Hour)
+	testLockers(t, a, b)
+
+	// Expired claims are forgotten
+	c, _ := NewFile(path, time.Nanosecond)
+	if claimed, _ := c.TryLock("expiring"); !claimed {
+		t.Fatalf("TryLock of a new key was not claimed")
+	}
+	time.Sleep(time.Millisecond)
+	if claimed, _ := c.TryLock("expiring"); !claimed {
+		t.Errorf("TryLock of a expired key was not claimed")
+	}
+}
+
+func TestSchedulerLocker(t *testing.T) {
+	locker, _ := NewFile(filepath.Join(t.TempDir(), "locks"), 1*time.Hour)
+	fc := clock.NewFake(testTime)
+	runs := make(chan struct{}, 10)
+	var skipped []<-chan taskmanager.Event
+	for i := 0; i < 2; i++ {
+		s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(fc), taskmanager.WithLocker(locker))
+		defer s.Shutdown(context.Background())
+		skipped = append(skipped, s.Subscribe(taskmanager.EventTypes(taskmanager.EventType_Skipped)))
+		timer, _ := taskmanager.NewCron("0 * * * *")
+		_ = s.Add(context.Background(), "export", timer, func(ctx context.Context) { runs <- struct{}{} })
+		_ = s.Start("export")
+	}
+
+	fc.Advance(1 * time.Hour)
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the run")
+	}
+	select {
+	case e := <-skipped[0]:
+		if !e.Scheduled.Equal(testTime.Add(1 * time.Hour)) {
+			t.Errorf("Skipped Event %+v", e)
+		}
+	case e := <-skipped[1]:
+		if !e.Scheduled.Equal(testTime.Add(1 * time.Hour)) {
+			t.Errorf("Skipped Event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the Skipped Event")
+	}
+	select {
+	case <-runs:
+		t.Errorf("The scheduled run was dispatched twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
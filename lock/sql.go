@@ -0,0 +1,98 @@
+package lock
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+)
+
+var _ taskmanager.Locker = (*SQL)(nil)
+
+var validTable = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+//SQL A Locker that claims runs by inserting a lease into a table of a database shared by the Schedulers. The table
+//is created by NewSQL if it does not exist:
+//
+//	CREATE TABLE <table> (lock_key VARCHAR(255) PRIMARY KEY, owner VARCHAR(255) NOT NULL, expires BIGINT NOT NULL)
+//
+//Leases are deleted once they expire, after the retention given to NewSQL, which must be longer than the clocks of
+//the Schedulers can disagree by. Queries use ? placeholders, as SQLite and MySQL do, unless DollarPlaceholders is
+//called for PostgreSQL.
+type SQL struct {
+	db        *sql.DB
+	table     string
+	owner     string
+	retention time.Duration
+	dollar    bool
+}
+
+//NewSQL Returns a SQL Locker leasing runs in table of db, on behalf of owner, which should be unique to each
+//Scheduler, such as the host name or a UUID. Return error if the table can not be created.
+func NewSQL(db *sql.DB, table string, owner string, retention time.Duration) (*SQL, error) {
+	if !validTable.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	if retention <= 0 {
+		return nil, fmt.Errorf("invalid retention, must be > 0")
+	}
+	l := &SQL{db: db, table: table, owner: owner, retention: retention}
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS " + table + " (lock_key VARCHAR(255) PRIMARY KEY, owner VARCHAR(255) NOT NULL, expires BIGINT NOT NULL)")
+	if err != nil {
+		return nil, fmt.Errorf("creating lock table %s: %w", table, err)
+	}
+	return l, nil
+}
+
+//DollarPlaceholders Use $1, $2... placeholders in queries, as PostgreSQL does. Returns l.
+func (l *SQL) DollarPlaceholders() *SQL {
+	l.dollar = true
+	return l
+}
+
+//TryLock Claim the run identified by key by inserting a lease for it. Returns false if another owner holds a lease
+//for it, or true if this owner already does.
+func (l *SQL) TryLock(key string) (bool, error) {
+	now := time.Now()
+	if _, err := l.db.Exec(l.query("DELETE FROM "+l.table+" WHERE expires < ?"), now.UnixNano()); err != nil {
+		return false, fmt.Errorf("deleting expired leases: %w", err)
+	}
+	_, insertErr := l.db.Exec(l.query("INSERT INTO "+l.table+" (lock_key, owner, expires) VALUES (?, ?, ?)"), key, l.owner, now.Add(l.retention).UnixNano())
+	if insertErr == nil {
+		return true, nil
+	}
+
+	// The insert fails if the key is already leased, so check who holds it
+	var owner string
+	err := l.db.QueryRow(l.query("SELECT owner FROM "+l.table+" WHERE lock_key = ?"), key).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("inserting lease: %w", insertErr)
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading lease: %w", err)
+	}
+	return owner == l.owner, nil
+}
+
+// query rewrites the ? placeholders of q to $1, $2... if DollarPlaceholders was called
+func (l *SQL) query(q string) string {
+	if !l.dollar {
+		return q
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
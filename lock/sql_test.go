@@ -0,0 +1,43 @@
+//go:build cgo
+// +build cgo
+
+// go-sqlite3 requires cgo, so the SQL Locker is only tested when it is enabled
+
+package lock
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks.db")
+	open := func(owner string) *SQL {
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		l, err := NewSQL(db, "task_locks", owner, 1*time.Hour)
+		if err != nil {
+			t.Fatalf("NewSQL Returned Error: %s", err.Error())
+		}
+		return l
+	}
+	a, b := open("a"), open("b")
+	testLockers(t, a, b)
+	if claimed, err := a.TryLock(taskmanager.LockKey("export", testTime)); err != nil || !claimed {
+		t.Errorf("TryLock of a key held by the same owner Returned %t, %v", claimed, err)
+	}
+	if _, err := NewSQL(a.db, "locks; DROP TABLE task_locks", "a", time.Hour); err == nil {
+		t.Errorf("NewSQL accepted a invalid table name")
+	}
+	if q := a.DollarPlaceholders().query("SELECT ? , ?"); q != "SELECT $1 , $2" {
+		t.Errorf("Query rewritten as %q", q)
+	}
+}
@@ -0,0 +1,63 @@
+package taskmanager
+
+import (
+	"time"
+
+	schedmetrics "github.com/Fishwaldo/go-taskmanager/metrics"
+	"github.com/armon/go-metrics"
+)
+
+//Locker claims the scheduled runs of Tasks, so that when several Schedulers with the same Tasks share a Locker, such
+//as replicas of a service, each scheduled run is only dispatched by one of them. The others skip the run, reporting
+//it with a EventType_Skipped Event and the SkippedJobs metric. Runs started with RunNow are not claimed.
+//
+//Runs are identified by the ID of the Task and the time they were scheduled for, so the replicas must agree on the
+//time a run is scheduled for. This is the case for Timers that fire at fixed times, such as Cron, but not for those
+//that fire relative to when they were started, such as Fixed.
+//Implementations must be safe for concurrent use. See the lock package for a file and a database/sql Locker.
+type Locker interface {
+	// TryLock Claim the run identified by key. Returns false if it was already claimed by another Scheduler.
+	TryLock(key string) (bool, error)
+}
+
+//LockKey Returns the key a Locker claims the run of the Task with the given id, scheduled for scheduled, with
+func LockKey(id string, scheduled time.Time) string {
+	return id + "@" + scheduled.UTC().Format(time.RFC3339Nano)
+}
+
+type lockerOption struct {
+	locker Locker
+}
+
+func (l lockerOption) apply(opts *taskoptions) {
+	opts.locker = l.locker
+}
+
+//WithLocker Claim every scheduled run with locker before dispatching it, see Locker. A run that can not be claimed,
+//because another Scheduler claimed it or locker returned a error, is skipped.
+func WithLocker(locker Locker) Option {
+	return lockerOption{locker: locker}
+}
+
+// claim claims the run of schedule scheduled for scheduled with the Locker. If it can not be claimed, the run is
+// reported as skipped, and the Task is rescheduled unless it is a catch up run.
+func (s *Scheduler) claim(schedule *Task, scheduled time.Time, catchUp bool) bool {
+	key := LockKey(schedule.id, scheduled)
+	claimed, err := s.locker.TryLock(key)
+	if err == nil && claimed {
+		return true
+	}
+	event := Event{Type: EventType_Skipped, Scheduled: scheduled}
+	if err != nil {
+		event.Error = err.Error()
+		s.log.Error(err, "Claiming Scheduled Run Failed, Skipping it", "jobid", schedule.id, "key", key)
+	} else {
+		s.log.Info("Scheduled Run Claimed Elsewhere, Skipping it", "jobid", schedule.id, "key", key)
+	}
+	metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_SkippedJobs), 1, []metrics.Label{{Name: "id", Value: schedule.id}})
+	s.emit(schedule.id, event)
+	if !catchUp {
+		schedule.reschedule()
+	}
+	return false
+}
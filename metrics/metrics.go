@@ -7,6 +7,7 @@ import (
 const (
 	Metrics_Guage_Up = iota
 	Metrics_Guage_Jobs
+	Metrics_Guage_DispatchQueueDepth
 )
 
 const (
@@ -38,6 +39,18 @@ const (
 	Metrics_Counter_MW_ConstantBackoff_Retries
 	Metrics_Counter_MW_ExpBackoff_Retries
 	Metrics_Counter_MW_RetryLimit_Hit
+	Metrics_Counter_StaleDispatches
+	Metrics_Counter_RunNow
+	Metrics_Counter_TimedOutJobs
+	Metrics_Counter_AbandonedJobs
+	Metrics_Counter_CancelledJobs
+	Metrics_Counter_DroppedEvents
+	Metrics_Counter_SkippedJobs
+	Metrics_Counter_Misfires
+)
+
+const (
+	Metrics_Summary_DispatchWait = iota
 )
 
 type GaugeValues struct {
@@ -68,6 +81,11 @@ var MetricsGauges = func() map[int]GaugeValues {
 			Name: []string{"sched", "jobs"},
 			Help: "Number of Jobs Scheduled",
 		},
+	Metrics_Guage_DispatchQueueDepth:
+		{
+			Name: []string{"sched", "dispatch", "queuedepth"},
+			Help: "Number of Runs waiting for a free Worker",
+		},
 	}
 }
 
@@ -213,12 +231,57 @@ var MetricsCounter = func() map[int]CounterValues {
 			Name: []string{"sched", "middleware", "retrylimit", "hit"},
 			Help: "Number of times the Retry Limit Middleware Canceled a pending job",
 		},
+	Metrics_Counter_StaleDispatches:
+		{
+			Name: []string{"sched", "dispatch", "stale"},
+			Help: "Number of Runs that waited too long for a free Worker",
+		},
+	Metrics_Counter_RunNow:
+		{
+			Name: []string{"sched", "runnow"},
+			Help: "Number of Runs started outside of the Schedule with RunNow",
+		},
+	Metrics_Counter_TimedOutJobs:
+		{
+			Name: []string{"sched", "timedoutjobs"},
+			Help: "Number of Job Runs that exceeded their Run Timeout",
+		},
+	Metrics_Counter_AbandonedJobs:
+		{
+			Name: []string{"sched", "abandonedjobs"},
+			Help: "Number of Job Runs abandoned after ignoring the cancellation of a Run Timeout",
+		},
+	Metrics_Counter_CancelledJobs:
+		{
+			Name: []string{"sched", "cancelledjobs"},
+			Help: "Number of Job Runs cancelled with CancelRun or CancelAllRuns",
+		},
+	Metrics_Counter_DroppedEvents:
+		{
+			Name: []string{"sched", "droppedevents"},
+			Help: "Number of lifecycle Events dropped because a subscriber was not keeping up",
+		},
+	Metrics_Counter_SkippedJobs:
+		{
+			Name: []string{"sched", "skippedjobs"},
+			Help: "Number of scheduled Runs skipped because they were claimed by another Scheduler, or could not be claimed",
+		},
+	Metrics_Counter_Misfires:
+		{
+			Name: []string{"sched", "misfires"},
+			Help: "Number of scheduled Runs missed because the process was down or the machine slept through them",
+		},
 
 	}
 }
 
 var MetricsSummary = func() map[int]SummaryValues {
 	return map[int]SummaryValues {
+	Metrics_Summary_DispatchWait:
+		{
+			Name: []string{"sched", "dispatch", "waittime"},
+			Help: "Seconds a Run waited for a free Worker",
+		},
 	}
 }
 
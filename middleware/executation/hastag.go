@@ -1,6 +1,7 @@
 package executionmiddleware
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/Fishwaldo/go-taskmanager"
@@ -69,14 +70,39 @@ func (hth *HasTagHandler) IsRequiredTag(tag string) bool {
 	return ok
 }
 
+// HaveTags Returns the tags of the resources that are available, sorted
+func (hth *HasTagHandler) HaveTags() []string {
+	hth.mx.RLock()
+	defer hth.mx.RUnlock()
+	return sortedTags(hth.haveTags)
+}
+
+// RequiredTags Returns the tags of the resources a job requires, sorted
+func (hth *HasTagHandler) RequiredTags() []string {
+	hth.mx.RLock()
+	defer hth.mx.RUnlock()
+	return sortedTags(hth.requiredTags)
+}
+
+func sortedTags(tags map[string]bool) []string {
+	list := make([]string, 0, len(tags))
+	for tag := range tags {
+		list = append(list, tag)
+	}
+	sort.Strings(list)
+	return list
+}
+
 // Handler Runs the Tag Handler before a job is dispatched.
 func (hth *HasTagHandler) PreHandler(s *taskmanager.Task) (taskmanager.MWResult, error) {
+	// The tags can be changed while Jobs are dispatched, see config.Reloader
+	required := hth.RequiredTags()
 	s.Logger.
-		WithValues("present", hth.haveTags).
-		WithValues("required", hth.requiredTags).
+		WithValues("present", hth.HaveTags()).
+		WithValues("required", required).
 		V(1).
 		Info("Checking Tags")
-	for k := range hth.requiredTags {
+	for _, k := range required {
 		if !(hth.IsHaveTag(k)) {
 			s.Logger.
 				WithValues("tag", k).
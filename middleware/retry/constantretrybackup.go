@@ -17,7 +17,7 @@ var _ taskmanager.RetryMiddleware = (*RetryConstantBackoff)(nil)
 type constantCtxKey struct{}
 
 // RetryConstantBackoff is a Middleware that will retry jobs after failures.
-// By Default, it runs after Panics, Deferred Jobs (by other Middleware) or if OverLapped Jobs are prohibited.
+// By Default, it runs after Panics, Timeouts, Deferred Jobs (by other Middleware) or if OverLapped Jobs are prohibited.
 // It uses a Constant Backoff Scheme and is implemented by github.com/cenkalti/backoff/v4
 type RetryConstantBackoff struct {
 	mx       sync.RWMutex
@@ -81,5 +81,6 @@ func NewRetryConstantBackoff(interval time.Duration) *RetryConstantBackoff {
 	val.handleDeferred = true
 	val.handleOverlap = true
 	val.handlePanic = true
+	val.handleTimeout = true
 	return &val
 }
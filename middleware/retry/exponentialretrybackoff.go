@@ -16,7 +16,7 @@ var _ taskmanager.RetryMiddleware = (*RetryExponentialBackoff)(nil)
 type eboCtxKey struct{}
 
 // RetryExponentialBackoff will retry a job using a exponential backoff
-// By Default, it runs after Panics, Deferred Jobs (by other Middleware) or if OverLapped Jobs are prohibited.
+// By Default, it runs after Panics, Timeouts, Deferred Jobs (by other Middleware) or if OverLapped Jobs are prohibited.
 // It uses a Exponential Backoff Scheme and is implemented by github.com/cenkalti/backoff/v4
 type RetryExponentialBackoff struct {
 	mx sync.RWMutex
@@ -83,6 +83,7 @@ func NewRetryExponentialBackoff(ebo *backoff.ExponentialBackOff) *RetryExponenti
 		bo: ebo,
 	}
 	val.handlePanic = true
+	val.handleTimeout = true
 	val.handleOverlap = true
 	val.handleDeferred = true
 	return &val
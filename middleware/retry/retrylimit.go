@@ -2,6 +2,7 @@ package retrymiddleware
 
 import (
 	"context"
+	"strconv"
 	"sync"
 
 	"github.com/Fishwaldo/go-taskmanager"
@@ -11,11 +12,12 @@ import (
 )
 
 var _ taskmanager.RetryMiddleware = (*RetryCountLimit)(nil)
+var _ taskmanager.StatefulMiddleware = (*RetryCountLimit)(nil)
 
 type retryCountCtxKey struct{}
 
 // RetryConstantBackoff is a Middleware that will retry jobs after failures.
-// By Default, it runs after Panics, Deferred Jobs (by other Middleware) or if OverLapped Jobs are prohibited.
+// By Default, it runs after Panics, Timeouts, Deferred Jobs (by other Middleware) or if OverLapped Jobs are prohibited.
 // It uses a Constant Backoff Scheme and is implemented by github.com/cenkalti/backoff/v4
 type RetryCountLimit struct {
 	mx  sync.RWMutex
@@ -73,6 +75,33 @@ func (ebh *RetryCountLimit) Initilize(s *taskmanager.Task) {
 	ebh.Reset(s)
 }
 
+//SaveState Save the number of attempts made for s
+func (ebh *RetryCountLimit) SaveState(s *taskmanager.Task) ([]byte, error) {
+	ebh.mx.RLock()
+	defer ebh.mx.RUnlock()
+	bo, ok := ebh.getCtx(s)
+	if !ok {
+		return nil, joberrors.FailedJobError{ErrorType: joberrors.Error_Middleware, Message: "RetryCountLimit Not Reset/Initialzied"}
+	}
+	return []byte(strconv.Itoa(bo.attempts)), nil
+}
+
+//RestoreState Restore the number of attempts made for s saved by SaveState
+func (ebh *RetryCountLimit) RestoreState(s *taskmanager.Task, state []byte) error {
+	attempts, err := strconv.Atoi(string(state))
+	if err != nil {
+		return err
+	}
+	ebh.mx.Lock()
+	defer ebh.mx.Unlock()
+	bo, ok := ebh.getCtx(s)
+	if !ok {
+		return joberrors.FailedJobError{ErrorType: joberrors.Error_Middleware, Message: "RetryCountLimit Not Reset/Initialzied"}
+	}
+	bo.attempts = attempts
+	return nil
+}
+
 //NewDefaultRetryConstantBackoff Create a Constant Backoff Handler with 1 second
 func NewDefaultRetryCountLimit() *RetryCountLimit {
 	val := NewRetryRetryCountLimit(10)
@@ -87,5 +116,6 @@ func NewRetryRetryCountLimit(limit int) *RetryCountLimit {
 	val.handleDeferred = true
 	val.handleOverlap = true
 	val.handlePanic = true
+	val.handleTimeout = true
 	return &val
 }
@@ -10,6 +10,8 @@ type RetryMiddlewareOptions struct {
 	handlePanic    bool
 	handleOverlap  bool
 	handleDeferred bool
+	handleJobError bool
+	handleTimeout  bool
 }
 
 // HandlePanic Enable/Disable the ExponetialBackoff Handler for Panics
@@ -27,6 +29,16 @@ func (retryOptions *RetryMiddlewareOptions) HandleDeferred(val bool) {
 	retryOptions.handleDeferred = val
 }
 
+// HandleJobError Enable/Disable the Handler for Errors Returned by the Job. Disabled by Default
+func (retryOptions *RetryMiddlewareOptions) HandleJobError(val bool) {
+	retryOptions.handleJobError = val
+}
+
+// HandleTimeout Enable/Disable the Handler for Jobs that exceeded their Run Timeout
+func (retryOptions *RetryMiddlewareOptions) HandleTimeout(val bool) {
+	retryOptions.handleTimeout = val
+}
+
 func (retryOptions *RetryMiddlewareOptions) shouldHandleState(e error) bool {
 	var err joberrors.FailedJobError
 	if errors.As(e, &err) {
@@ -43,6 +55,14 @@ func (retryOptions *RetryMiddlewareOptions) shouldHandleState(e error) bool {
 			if retryOptions.handleDeferred {
 				return true
 			}
+		case joberrors.Error_JobError:
+			if retryOptions.handleJobError {
+				return true
+			}
+		case joberrors.Error_Timeout:
+			if retryOptions.handleTimeout {
+				return true
+			}
 		}
 	}
 	return false
@@ -0,0 +1,137 @@
+package taskmanager
+
+import (
+	"fmt"
+	"time"
+
+	schedmetrics "github.com/Fishwaldo/go-taskmanager/metrics"
+	"github.com/armon/go-metrics"
+)
+
+// maxMisfires is the most missed runs a misfire is counted up to
+const maxMisfires = 10000
+
+//MisfirePolicy What a Task does about the runs it missed, because the process was down or the machine slept through
+//them, see WithMisfirePolicy
+type MisfirePolicy int
+
+const (
+	// MisfirePolicy_RunOnce Run once, for the most recent missed run
+	MisfirePolicy_RunOnce MisfirePolicy = iota
+	// MisfirePolicy_Skip Do not run, and wait for the next run that was not missed
+	MisfirePolicy_Skip
+	// MisfirePolicy_RunAll Run once for every missed run, up to the limit given to WithMisfirePolicy, in which case
+	// only the most recent missed runs are run
+	MisfirePolicy_RunAll
+)
+
+func (m MisfirePolicy) String() string {
+	switch m {
+	case MisfirePolicy_RunOnce:
+		return "RUNONCE"
+	case MisfirePolicy_Skip:
+		return "SKIP"
+	case MisfirePolicy_RunAll:
+		return "RUNALL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+//MarshalText Encodes the MisfirePolicy as its name
+func (m MisfirePolicy) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+//UnmarshalText Decodes a MisfirePolicy encoded with MarshalText
+func (m *MisfirePolicy) UnmarshalText(text []byte) error {
+	for v := MisfirePolicy_RunOnce; v <= MisfirePolicy_RunAll; v++ {
+		if v.String() == string(text) {
+			*m = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown misfire policy %q", string(text))
+}
+
+type misfireOption struct {
+	policy    MisfirePolicy
+	threshold time.Duration
+	limit     int
+}
+
+func (m misfireOption) apply(opts *taskoptions) {
+	opts.misfirePolicy = m.policy
+	opts.misfireThreshold = m.threshold
+	opts.misfireLimit = m.limit
+}
+
+func (m misfireOption) taskDefault() {}
+
+//WithMisfirePolicy Handle a run that is dispatched more than threshold after it was scheduled as a misfire, according
+//to policy. This happens when the process was down, with the next run restored from a Store (see WithStore), or the
+//machine slept through it. The runs missed since are worked out with the Timer of the Task if it implements
+//Forecaster. With MisfirePolicy_RunAll, at most limit runs are dispatched together. Every misfire is reported with a
+//EventType_Misfired Event and the Misfires metric. By default there is no threshold, and a late run is dispatched
+//once without being reported.
+func WithMisfirePolicy(policy MisfirePolicy, threshold time.Duration, limit int) Option {
+	return misfireOption{policy: policy, threshold: threshold, limit: limit}
+}
+
+// misfires returns the runs missed since scheduled, oldest first, if now is more than the misfire threshold after
+// scheduled, otherwise nil
+func (s *Task) misfires(scheduled time.Time, now time.Time) []time.Time {
+	s.mx.RLock()
+	threshold := s.misfireThreshold
+	s.mx.RUnlock()
+	if threshold <= 0 || now.Sub(scheduled) <= threshold {
+		return nil
+	}
+	missed := []time.Time{scheduled}
+	for t := scheduled; len(missed) < maxMisfires; {
+		next, done := s.nextAfter(t)
+		if done || next.After(now) || !next.After(t) {
+			break
+		}
+		missed = append(missed, next)
+		t = next
+	}
+	return missed
+}
+
+// dispatchDue dispatches the run of schedule scheduled for scheduled, applying its MisfirePolicy if it was missed.
+// It is called from the Scheduler loop, so it must not wait on updateScheduleChan.
+func (s *Scheduler) dispatchDue(schedule *Task, scheduled time.Time) {
+	missed := schedule.misfires(scheduled, s.clock.Now())
+	if missed == nil {
+		s.dispatch(schedule, scheduled, false)
+		return
+	}
+	schedule.mx.RLock()
+	policy, limit := schedule.misfirePolicy, schedule.misfireLimit
+	schedule.mx.RUnlock()
+
+	var runs []time.Time
+	switch policy {
+	case MisfirePolicy_Skip:
+	case MisfirePolicy_RunAll:
+		if limit < 1 {
+			limit = 1
+		}
+		runs = missed
+		if len(runs) > limit {
+			runs = runs[len(runs)-limit:]
+		}
+	default:
+		runs = missed[len(missed)-1:]
+	}
+	s.log.Info("Job Misfired", "jobid", schedule.id, "scheduled", scheduled, "missed", len(missed), "policy", policy, "runs", len(runs))
+	metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_Misfires), float32(len(missed)), []metrics.Label{{Name: "id", Value: schedule.id}, {Name: "policy", Value: policy.String()}})
+	s.emit(schedule.id, Event{Type: EventType_Misfired, Scheduled: scheduled, Missed: len(missed), Runs: len(runs)})
+	// The Task is rescheduled once here, and the runs are dispatched as catch up runs that leave the Timer alone
+	schedule.advance()
+	s.updateNextRun(schedule.id)
+	for _, run := range runs {
+		s.dispatch(schedule, run, true)
+	}
+}
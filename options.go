@@ -1,16 +1,40 @@
 package taskmanager
 
 import (
+	"encoding/json"
 	"log"
 	"os"
+	"time"
 	"github.com/go-logr/logr"
 	"github.com/go-logr/stdr"
+	"github.com/Fishwaldo/go-taskmanager/clock"
 )
 
 type taskoptions struct {
 	logger              logr.Logger
 	executationmiddlewares []ExecutionMiddleWare
 	retryMiddlewares	   []RetryMiddleware
+	clock               clock.Clock
+	shutdownCancelMargin time.Duration
+	maxConcurrentJobs   int
+	maxDispatchWait     time.Duration
+	staleDispatchPolicy StaleDispatchPolicy
+	priority            int
+	priorityAging       time.Duration
+	labels              map[string]string
+	runTimeout          time.Duration
+	hardKill            time.Duration
+	historySize         int
+	store               Store
+	locker              Locker
+	misfirePolicy       MisfirePolicy
+	misfireThreshold    time.Duration
+	misfireLimit        int
+	jitter              time.Duration
+	registry            *JobRegistry
+	restoreOptions      func(rec TaskRecord) []Option
+	jobType             string
+	params              json.RawMessage
 }
 
 
@@ -18,6 +42,8 @@ func defaultTaskOptions() *taskoptions {
 	logsink := log.New(os.Stdout, "", 0);
 	return &taskoptions{
 		logger:       stdr.New(logsink),
+		clock:        clock.New(),
+		historySize:  10,
 	}
 }
 
@@ -25,6 +51,9 @@ func defaultSchedOptions() *taskoptions {
 	logsink := log.New(os.Stdout, "", 0);
 	return &taskoptions {
 		logger: 	stdr.New(logsink),
+		clock:      clock.New(),
+		shutdownCancelMargin: time.Second,
+		priorityAging:        30 * time.Second,
 	}
 }
 
@@ -34,6 +63,13 @@ type Option interface {
 	apply(*taskoptions)
 }
 
+// taskDefaultOption is implemented by the Options that, given to NewScheduler, are defaults for its Tasks, which the
+// Options of a Task override
+type taskDefaultOption interface {
+	Option
+	taskDefault()
+}
+
 type loggerOption struct {
 	Logger logr.Logger
 }
@@ -71,3 +107,172 @@ func WithRetryMiddleWare(handler RetryMiddleware) Option {
 	return retryMiddleware{middleware: handler}
 }
 
+type clockOption struct {
+	clock clock.Clock
+}
+
+func (c clockOption) apply(opts *taskoptions) {
+	opts.clock = c.clock
+}
+
+//WithClock Use the supplied Clock for the Scheduler loop, Timers and Job timing instead of the wall clock.
+func WithClock(c clock.Clock) Option {
+	return clockOption{clock: c}
+}
+
+type shutdownCancelMarginOption struct {
+	margin time.Duration
+}
+
+func (s shutdownCancelMarginOption) apply(opts *taskoptions) {
+	opts.shutdownCancelMargin = s.margin
+}
+
+//WithShutdownCancelMargin How long before the deadline of the Context passed to Scheduler.Shutdown the Context of
+//running Jobs is canceled, giving them time to exit. Defaults to 1 second.
+func WithShutdownCancelMargin(margin time.Duration) Option {
+	return shutdownCancelMarginOption{margin: margin}
+}
+
+type maxConcurrentJobsOption struct {
+	max int
+}
+
+func (m maxConcurrentJobsOption) apply(opts *taskoptions) {
+	opts.maxConcurrentJobs = m.max
+}
+
+//WithMaxConcurrentJobs Run at most max Jobs at once across all Tasks of the Scheduler. Runs that become due while
+//max Jobs are running wait for a free worker. 0 (the default) means unlimited.
+func WithMaxConcurrentJobs(max int) Option {
+	return maxConcurrentJobsOption{max: max}
+}
+
+type maxDispatchWaitOption struct {
+	maxWait time.Duration
+	policy  StaleDispatchPolicy
+}
+
+func (m maxDispatchWaitOption) apply(opts *taskoptions) {
+	opts.maxDispatchWait = m.maxWait
+	opts.staleDispatchPolicy = m.policy
+}
+
+//WithMaxDispatchWait When used with WithMaxConcurrentJobs, runs that waited longer than maxWait for a free worker
+//are handled according to policy instead of being run.
+func WithMaxDispatchWait(maxWait time.Duration, policy StaleDispatchPolicy) Option {
+	return maxDispatchWaitOption{maxWait: maxWait, policy: policy}
+}
+
+type priorityOption struct {
+	priority int
+}
+
+func (p priorityOption) apply(opts *taskoptions) {
+	opts.priority = p.priority
+}
+
+func (p priorityOption) taskDefault() {}
+
+//WithPriority Set the Priority of a Task. When several Tasks are due at the same time, or runs are waiting for a
+//free worker (see WithMaxConcurrentJobs), Tasks with a higher priority are dispatched first. Defaults to 0.
+func WithPriority(priority int) Option {
+	return priorityOption{priority: priority}
+}
+
+type priorityAgingOption struct {
+	aging time.Duration
+}
+
+func (p priorityAgingOption) apply(opts *taskoptions) {
+	opts.priorityAging = p.aging
+}
+
+//WithPriorityAging Raise the priority of a run waiting for a free worker by 1 for every aging period it has waited,
+//so low priority Tasks are not starved by higher priority ones. Defaults to 30 seconds, 0 disables aging.
+func WithPriorityAging(aging time.Duration) Option {
+	return priorityAgingOption{aging: aging}
+}
+
+type labelsOption struct {
+	labels map[string]string
+}
+
+func (l labelsOption) apply(opts *taskoptions) {
+	if opts.labels == nil {
+		opts.labels = make(map[string]string, len(l.labels))
+	}
+	for k, v := range l.labels {
+		opts.labels[k] = v
+	}
+}
+
+//WithLabels Add Labels to a Task, so it can be selected with a LabelSelector (for example by
+//Scheduler.PauseMatching)
+func WithLabels(labels map[string]string) Option {
+	return labelsOption{labels: labels}
+}
+
+type runTimeoutOption struct {
+	timeout time.Duration
+}
+
+func (r runTimeoutOption) apply(opts *taskoptions) {
+	opts.runTimeout = r.timeout
+}
+
+func (r runTimeoutOption) taskDefault() {}
+
+//WithRunTimeout Cancel the Context passed to a Job once it has run for timeout. The run fails with a
+//joberrors.Error_Timeout error, which is passed to the Post Execution and Retry Middleware. Given to
+//NewScheduler it applies to every Task that does not set its own. 0 (the default) means no timeout.
+func WithRunTimeout(timeout time.Duration) Option {
+	return runTimeoutOption{timeout: timeout}
+}
+
+type hardKillOption struct {
+	grace time.Duration
+}
+
+func (h hardKillOption) apply(opts *taskoptions) {
+	opts.hardKill = h.grace
+}
+
+func (h hardKillOption) taskDefault() {}
+
+//WithRunHardKill Used with WithRunTimeout, stop waiting for a Job that has not returned grace after its Context was
+//canceled. The Job is abandoned and flagged with the job.ABANDONED State, but as Go can not kill it, it may keep
+//running. 0 (the default) means wait for the Job to return.
+func WithRunHardKill(grace time.Duration) Option {
+	return hardKillOption{grace: grace}
+}
+
+type historySizeOption struct {
+	size int
+}
+
+func (h historySizeOption) apply(opts *taskoptions) {
+	opts.historySize = h.size
+}
+
+func (h historySizeOption) taskDefault() {}
+
+//WithHistorySize Keep the last size runs of a Task, reported by History. Defaults to 10, 0 disables the History.
+func WithHistorySize(size int) Option {
+	return historySizeOption{size: size}
+}
+
+type storeOption struct {
+	store Store
+}
+
+func (so storeOption) apply(opts *taskoptions) {
+	opts.store = so.store
+}
+
+//WithStore Save the definition and run state of every Task in store, see Store. Given to NewScheduler, the saved
+//TaskRecords are loaded, and a Task added with the ID of a saved TaskRecord picks up its next run (unless its Timer
+//changed), Paused state, run counts, History and StatefulMiddleware state, and is Started if it was Started before.
+func WithStore(store Store) Option {
+	return storeOption{store: store}
+}
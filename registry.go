@@ -0,0 +1,242 @@
+package taskmanager
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/joberrors"
+)
+
+//JobFactory Creates the Job of a Task from its serialized parameters. params is nil if the Task has none.
+type JobFactory func(params json.RawMessage) (func(context.Context) error, error)
+
+//JobRegistry maps job type names to the JobFactory that creates their Jobs, so Tasks can be created from data
+//with Scheduler.AddJob, and rebuilt from a Store. Safe for concurrent use.
+type JobRegistry struct {
+	mx        sync.RWMutex
+	factories map[string]JobFactory
+}
+
+//NewJobRegistry Returns a empty JobRegistry
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{
+		factories: make(map[string]JobFactory),
+	}
+}
+
+//Register Register factory for jobType. Return error if jobType is already registered.
+func (r *JobRegistry) Register(jobType string, factory JobFactory) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	if _, ok := r.factories[jobType]; ok {
+		return joberrors.ErrorJobTypeExists{Message: "job type " + jobType + " is already registered"}
+	}
+	r.factories[jobType] = factory
+	return nil
+}
+
+//Types Returns the registered job types, sorted
+func (r *JobRegistry) Types() []string {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	types := make([]string, 0, len(r.factories))
+	for jobType := range r.factories {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+//Build Create a Job of jobType from params. Return error if jobType is not registered, or its JobFactory fails.
+func (r *JobRegistry) Build(jobType string, params json.RawMessage) (func(context.Context) error, error) {
+	r.mx.RLock()
+	factory, ok := r.factories[jobType]
+	r.mx.RUnlock()
+	if !ok {
+		return nil, joberrors.ErrorJobTypeNotFound{Message: "job type " + jobType + " is not registered"}
+	}
+	return factory(params)
+}
+
+type jobRegistryOption struct {
+	registry *JobRegistry
+}
+
+func (j jobRegistryOption) apply(opts *taskoptions) {
+	opts.registry = j.registry
+}
+
+//WithJobRegistry Create the Jobs of Tasks added with Scheduler.AddJob from registry. Given with WithStore, Tasks
+//saved with a job type are rebuilt by NewScheduler, with the Options saved in their TaskRecord.
+func WithJobRegistry(registry *JobRegistry) Option {
+	return jobRegistryOption{registry: registry}
+}
+
+type restoreOptionsOption struct {
+	restoreOptions func(rec TaskRecord) []Option
+}
+
+func (r restoreOptionsOption) apply(opts *taskoptions) {
+	opts.restoreOptions = r.restoreOptions
+}
+
+//WithRestoreOptions Given with WithStore and WithJobRegistry, fn returns extra Options for a Task saved with a job
+//type when NewScheduler rebuilds it from rec, such as its Execution and Retry Middleware, which can not be saved in
+//the TaskRecord. They are applied after the Options saved in rec.
+func WithRestoreOptions(fn func(rec TaskRecord) []Option) Option {
+	return restoreOptionsOption{restoreOptions: fn}
+}
+
+//AddJob Create a new Task that runs a Job of jobType, created from params by the JobRegistry of the Scheduler (see
+//WithJobRegistry), according to the Timer described by timerSpec (see ParseTimer). Unlike Add, the job type and
+//params are saved in the Store, so the Task can be rebuilt after a restart. Return error if the Scheduler has no
+//JobRegistry, timerSpec is invalid, or the Job can not be created.
+func (s *Scheduler) AddJob(ctx context.Context, id string, timerSpec string, jobType string, params json.RawMessage, extraOpts ...Option) error {
+	if s.registry == nil {
+		return joberrors.ErrorJobTypeNotFound{Message: "scheduler has no job registry"}
+	}
+	timer, err := ParseTimer(timerSpec)
+	if err != nil {
+		return err
+	}
+	jobFunc, err := s.registry.Build(jobType, params)
+	if err != nil {
+		return err
+	}
+	opts := make([]Option, 0, len(extraOpts)+1)
+	opts = append(opts, jobTypeOption{jobType: jobType, params: params})
+	opts = append(opts, extraOpts...)
+	return s.AddWithError(ctx, id, timer, jobFunc, opts...)
+}
+
+//UpdateJob Replace the Timer, Job and Options of the Task id, which is otherwise the same as removing it and
+//adding it again with AddJob, except that its Started and Paused state, statistics, History and active Job instances
+//are kept. The next run is only recalculated if timerSpec changed. Return error if the Task does not exist, the
+//Scheduler has no JobRegistry, timerSpec is invalid, or the Job can not be created.
+func (s *Scheduler) UpdateJob(id string, timerSpec string, jobType string, params json.RawMessage, extraOpts ...Option) error {
+	if s.registry == nil {
+		return joberrors.ErrorJobTypeNotFound{Message: "scheduler has no job registry"}
+	}
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+	timer, err := ParseTimer(timerSpec)
+	if err != nil {
+		return err
+	}
+	jobFunc, err := s.registry.Build(jobType, params)
+	if err != nil {
+		return err
+	}
+	// Options are applied in the same order as AddWithError does
+	options := defaultTaskOptions()
+	opts := make([]Option, 0, len(extraOpts)+1)
+	opts = append(opts, jobTypeOption{jobType: jobType, params: params})
+	opts = append(opts, extraOpts...)
+	for _, option := range s.taskOptions(opts) {
+		option.apply(options)
+	}
+
+	// Take the Task off the run queue while its Timer and priority change
+	s.removeFromRunQueue(id)
+	timerChanged := schedule.update(timer, jobFunc, options)
+	if schedule.isStarted() && !schedule.IsPaused() {
+		s.addScheduletoRunQueue(schedule)
+	}
+	schedule.persist()
+	s.emit(id, Event{Type: EventType_Updated})
+	s.log.Info("Updated Job", "jobid", id, "timerchanged", timerChanged)
+	return nil
+}
+
+//TaskDefinition The Timer, Job and Options of a Task and its next run, saved by Scheduler.Definition so they can be
+//put back by Scheduler.RestoreDefinition
+type TaskDefinition struct {
+	id      string
+	timer   Timer
+	jobFunc func(context.Context) error
+	options taskoptions
+	nextRun time.Time
+}
+
+//Definition Returns the Timer, Job and Options of the Task id, so a UpdateJob that is part of a larger change can be
+//undone with RestoreDefinition. Return error if the Task does not exist.
+func (s *Scheduler) Definition(id string) (*TaskDefinition, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.definition(), nil
+}
+
+//RestoreDefinition Put back the Timer, Job, Options and next run of the Task saved by Definition, undoing the
+//UpdateJob calls made since. Its Started and Paused state, statistics and History are kept. Unlike UpdateJob, the
+//Middleware that is put back is not initialized again, so it keeps its state, unless it implements
+//TeardownMiddleWare. Return error if the Task no longer exists.
+func (s *Scheduler) RestoreDefinition(def *TaskDefinition) error {
+	schedule, err := s.GetSchedule(def.id)
+	if err != nil {
+		return err
+	}
+	s.removeFromRunQueue(def.id)
+	schedule.redefine(def)
+	if schedule.isStarted() && !schedule.IsPaused() {
+		s.addScheduletoRunQueue(schedule)
+	}
+	schedule.persist()
+	s.emit(def.id, Event{Type: EventType_Updated})
+	s.log.Info("Restored Job Definition", "jobid", def.id)
+	return nil
+}
+
+// restoreJobs rebuilds the Tasks saved in the Store with a job type, with the Options saved in their TaskRecord and
+// those given by WithRestoreOptions
+func (s *Scheduler) restoreJobs() {
+	for _, rec := range s.storedJobs() {
+		opts := rec.options()
+		if s.restoreOptions != nil {
+			opts = append(opts, s.restoreOptions(rec)...)
+		}
+		err := s.AddJob(context.Background(), rec.ID, rec.Timer, rec.JobType, rec.Params, opts...)
+		if err != nil {
+			s.log.Error(err, "Restoring Task from Store Failed", "jobid", rec.ID, "jobtype", rec.JobType)
+		}
+	}
+}
+
+// storedJobs returns the loaded TaskRecords with a job type, sorted by ID
+func (s *Scheduler) storedJobs() []TaskRecord {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	recs := make([]TaskRecord, 0, len(s.stored))
+	for _, rec := range s.stored {
+		if rec.JobType != "" {
+			recs = append(recs, rec)
+		}
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].ID < recs[j].ID })
+	return recs
+}
+
+// jobTypeOption records the job type and params a Task was created from by AddJob
+type jobTypeOption struct {
+	jobType string
+	params  json.RawMessage
+}
+
+func (j jobTypeOption) apply(opts *taskoptions) {
+	opts.jobType = j.jobType
+	opts.params = j.params
+}
+
+//Registered Returns true if jobType is registered
+func (r *JobRegistry) Registered(jobType string) bool {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	_, ok := r.factories[jobType]
+	return ok
+}
@@ -0,0 +1,234 @@
+package taskmanager
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
+)
+
+// isoPeriod is a ISO 8601 duration, with the years, months and days kept apart as they vary in length
+type isoPeriod struct {
+	years, months, days int
+	duration            time.Duration
+}
+
+// addTo returns t plus n periods
+func (p isoPeriod) addTo(t time.Time, n int) time.Time {
+	return t.AddDate(n*p.years, n*p.months, n*p.days).Add(time.Duration(n) * p.duration)
+}
+
+// approx returns the average length of the period
+func (p isoPeriod) approx() time.Duration {
+	const day = 24 * time.Hour
+	return time.Duration(p.years)*365*day + time.Duration(p.years)*day/4 + time.Duration(p.months)*day*3044/100 + time.Duration(p.days)*day + p.duration
+}
+
+func (p isoPeriod) String() string {
+	var b strings.Builder
+	b.WriteString("P")
+	for _, part := range []struct {
+		n    int
+		unit string
+	}{{p.years, "Y"}, {p.months, "M"}, {p.days, "D"}} {
+		if part.n != 0 {
+			b.WriteString(strconv.Itoa(part.n) + part.unit)
+		}
+	}
+	if p.duration != 0 || b.Len() == 1 {
+		b.WriteString("T")
+		d := p.duration
+		if h := d / time.Hour; h != 0 {
+			b.WriteString(strconv.FormatInt(int64(h), 10) + "H")
+			d -= h * time.Hour
+		}
+		if m := d / time.Minute; m != 0 {
+			b.WriteString(strconv.FormatInt(int64(m), 10) + "M")
+			d -= m * time.Minute
+		}
+		if d != 0 || b.String() == "PT" {
+			b.WriteString(strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "S")
+		}
+	}
+	return b.String()
+}
+
+// parseISOPeriod parses a ISO 8601 duration, such as P1DT12H, PT1.5S or P2W
+func parseISOPeriod(s string) (isoPeriod, error) {
+	var p isoPeriod
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return p, fmt.Errorf("invalid duration %q", s)
+	}
+	rest := s[1:]
+	inTime := false
+	for rest != "" {
+		if rest[0] == 'T' && !inTime {
+			inTime = true
+			rest = rest[1:]
+			if rest == "" {
+				return p, fmt.Errorf("invalid duration %q", s)
+			}
+			continue
+		}
+		end := strings.IndexAny(rest, "YMWDHS")
+		if end < 1 {
+			return p, fmt.Errorf("invalid duration %q", s)
+		}
+		num, unit := rest[:end], rest[end]
+		rest = rest[end+1:]
+		if inTime {
+			v, err := strconv.ParseFloat(num, 64)
+			if err != nil || v < 0 {
+				return p, fmt.Errorf("invalid duration %q", s)
+			}
+			switch unit {
+			case 'H':
+				p.duration += time.Duration(v * float64(time.Hour))
+			case 'M':
+				p.duration += time.Duration(v * float64(time.Minute))
+			case 'S':
+				p.duration += time.Duration(v * float64(time.Second))
+			default:
+				return p, fmt.Errorf("invalid duration %q", s)
+			}
+			continue
+		}
+		v, err := strconv.Atoi(num)
+		if err != nil || v < 0 {
+			return p, fmt.Errorf("invalid duration %q, only the time can have fractions", s)
+		}
+		switch unit {
+		case 'Y':
+			p.years += v
+		case 'M':
+			p.months += v
+		case 'W':
+			p.days += 7 * v
+		case 'D':
+			p.days += v
+		default:
+			return p, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+	return p, nil
+}
+
+//Repeating A Timer that fires a number of times, or forever, at a fixed period from a start time, as described by a
+//ISO 8601 repeating interval. Periods of years, months or days follow the calendar of the location of the start time,
+//so P1M fires on the same day of every month.
+type Repeating struct {
+	start  time.Time
+	period isoPeriod
+	count  int
+	next   int
+	delay  time.Duration
+	clock  clock.Clock
+}
+
+//NewRepeating Returns a Repeating Timer that fires count times, or forever if count is < 0, every period starting at
+//start.
+func NewRepeating(start time.Time, period time.Duration, count int) (*Repeating, error) {
+	if period <= 0 {
+		return nil, fmt.Errorf("invalid period, must be > 0")
+	}
+	return &Repeating{start: start, period: isoPeriod{duration: period}, count: count, clock: clock.New()}, nil
+}
+
+//ParseRepeating Returns a Repeating Timer from a ISO 8601 repeating interval, Rn/<start>/<period>, where the start is
+//a RFC3339 time and the period a ISO 8601 duration. It fires n times, or forever if n is left out, so
+//R5/2026-01-01T00:00:00Z/PT1H fires every hour from midnight to 04:00. The end of the first interval can be given
+//instead of the period, as in R5/2026-01-01T00:00:00Z/2026-01-01T01:00:00Z.
+func ParseRepeating(spec string) (*Repeating, error) {
+	parts := strings.Split(strings.TrimSpace(spec), "/")
+	if len(parts) != 3 || !strings.HasPrefix(parts[0], "R") {
+		return nil, fmt.Errorf("invalid repeating interval %q, expected Rn/<start>/<period>", spec)
+	}
+	count := -1
+	if n := parts[0][1:]; n != "" {
+		var err error
+		if count, err = strconv.Atoi(n); err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid repeating interval %q, invalid number of repetitions", spec)
+		}
+	}
+	start, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid repeating interval %q: %w", spec, err)
+	}
+	var period isoPeriod
+	if strings.HasPrefix(parts[2], "P") {
+		if period, err = parseISOPeriod(parts[2]); err != nil {
+			return nil, fmt.Errorf("invalid repeating interval %q: %w", spec, err)
+		}
+	} else {
+		end, err := time.Parse(time.RFC3339Nano, parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid repeating interval %q: %w", spec, err)
+		}
+		period.duration = end.Sub(start)
+	}
+	if !period.addTo(start, 1).After(start) {
+		return nil, fmt.Errorf("invalid repeating interval %q, period must be > 0", spec)
+	}
+	return &Repeating{start: start, period: period, count: count, clock: clock.New()}, nil
+}
+
+// run returns the time of the first run at or after t (after t if strict) and its index, or false if there is none
+func (r *Repeating) run(t time.Time, from int, strict bool) (time.Time, int, bool) {
+	n := from
+	if est := int(t.Sub(r.start)/r.period.approx()) - 1; est > n {
+		n = est
+	}
+	// The estimate of a calendar period can be off, so step back to a run before t
+	for n > from && !r.period.addTo(r.start, n).Before(t) {
+		n--
+	}
+	for ; r.count < 0 || n < r.count; n++ {
+		next := r.period.addTo(r.start, n)
+		if next.After(t) || (!strict && next.Equal(t)) {
+			return next, n, true
+		}
+	}
+	return time.Time{}, n, false
+}
+
+//Next Return Next fire time, or done once it fired the number of times it repeats
+func (r *Repeating) Next() (time.Time, bool) {
+	now := r.clock.Now()
+	if r.delay > 0 {
+		next := now.Add(r.delay)
+		r.delay = 0
+		return next, false
+	}
+	next, n, ok := r.run(now, r.next, false)
+	if !ok {
+		return time.Time{}, true
+	}
+	r.next = n + 1
+	return next, false
+}
+
+func (r *Repeating) Reschedule(d time.Duration) {
+	r.delay = d
+}
+
+//Spec Returns the ISO 8601 repeating interval
+func (r *Repeating) Spec() string {
+	count := ""
+	if r.count >= 0 {
+		count = strconv.Itoa(r.count)
+	}
+	return "R" + count + "/" + r.start.Format(time.RFC3339Nano) + "/" + r.period.String()
+}
+
+//NextAfter Returns the first run after t, or done if there is none
+func (r *Repeating) NextAfter(t time.Time) (time.Time, bool) {
+	next, _, ok := r.run(t, 0, true)
+	return next, !ok
+}
+
+//SetClock Use c to determine the current time
+func (r *Repeating) SetClock(c clock.Clock) {
+	r.clock = c
+}
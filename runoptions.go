@@ -0,0 +1,23 @@
+package taskmanager
+
+type runOptions struct {
+	bypassMiddleware bool
+}
+
+// RunOption to customize a run started with Scheduler.RunNow, check the WithRun*() functions that
+// implement RunOption interface for the available options
+type RunOption interface {
+	apply(*runOptions)
+}
+
+type bypassMiddlewareOption struct{}
+
+func (bypassMiddlewareOption) apply(opts *runOptions) {
+	opts.bypassMiddleware = true
+}
+
+//WithRunBypassMiddleware Run the Job without running the Execution Middleware first, or the Post Execution
+//Middleware afterwards
+func WithRunBypassMiddleware() RunOption {
+	return bypassMiddlewareOption{}
+}
@@ -0,0 +1,129 @@
+package taskmanager
+
+import (
+	"container/heap"
+	"time"
+)
+
+// runQueue is a min-heap of Tasks ordered by their next run time and indexed by Task ID, so a Task can be
+// inserted, removed or have its position updated in O(log n). Tasks due at the same time are ordered by
+// priority, highest first. Tasks without a next run (zero time) sort last.
+// runQueue is not concurrent safe, the Scheduler protects it with tsmx.
+type runQueue struct {
+	items runQueueHeap
+	index map[string]*runQueueItem
+}
+
+type runQueueItem struct {
+	task *Task
+	// when is a copy of the Task's next run at the time it was last pushed or updated, the heap must not
+	// be ordered by a value that can change underneath it.
+	when time.Time
+	// priority is a copy of the Task's priority, which UpdateJob can change, for the same reason
+	priority int
+	pos      int
+}
+
+func newRunQueue() *runQueue {
+	return &runQueue{
+		index: make(map[string]*runQueueItem),
+	}
+}
+
+// Len returns the number of Tasks in the queue, including those without a next run
+func (rq *runQueue) Len() int {
+	return len(rq.items)
+}
+
+// push adds a Task to the queue, or updates its position if it is already queued
+func (rq *runQueue) push(t *Task) {
+	if _, ok := rq.index[t.id]; ok {
+		rq.update(t.id)
+		return
+	}
+	item := &runQueueItem{task: t, when: t.GetNextRun(), priority: t.GetPriority()}
+	rq.index[t.id] = item
+	heap.Push(&rq.items, item)
+}
+
+// remove removes the Task with the given id from the queue, returns false if it was not queued
+func (rq *runQueue) remove(id string) bool {
+	item, ok := rq.index[id]
+	if !ok {
+		return false
+	}
+	heap.Remove(&rq.items, item.pos)
+	delete(rq.index, id)
+	return true
+}
+
+// update re-reads the next run of the Task with the given id and fixes its position in the queue. returns false
+// if the Task is not queued
+func (rq *runQueue) update(id string) bool {
+	item, ok := rq.index[id]
+	if !ok {
+		return false
+	}
+	item.when = item.task.GetNextRun()
+	item.priority = item.task.GetPriority()
+	heap.Fix(&rq.items, item.pos)
+	return true
+}
+
+// get returns the queued Task with the given id
+func (rq *runQueue) get(id string) (*Task, bool) {
+	item, ok := rq.index[id]
+	if !ok {
+		return nil, false
+	}
+	return item.task, true
+}
+
+// peek returns the Task that is due to run next, or nil if no queued Task has a next run
+func (rq *runQueue) peek() *Task {
+	if len(rq.items) == 0 || rq.items[0].when.IsZero() {
+		return nil
+	}
+	return rq.items[0].task
+}
+
+type runQueueHeap []*runQueueItem
+
+func (h runQueueHeap) Len() int {
+	return len(h)
+}
+
+func (h runQueueHeap) Less(i, j int) bool {
+	if h[i].when.IsZero() != h[j].when.IsZero() {
+		return h[j].when.IsZero()
+	}
+	if !h[i].when.Equal(h[j].when) {
+		return h[i].when.Before(h[j].when)
+	}
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].task.id < h[j].task.id
+}
+
+func (h runQueueHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *runQueueHeap) Push(x interface{}) {
+	item := x.(*runQueueItem)
+	item.pos = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *runQueueHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.pos = -1
+	*h = old[:n-1]
+	return item
+}
@@ -0,0 +1,142 @@
+package taskmanager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/go-logr/logr"
+)
+
+func newQueueTestTask(b testing.TB, fc *clock.Fake, id string, every time.Duration) *Task {
+	b.Helper()
+	timer, err := NewFixed(every)
+	if err != nil {
+		b.Fatalf("NewFixed Returned Error: %s", err.Error())
+	}
+	return NewSchedule(context.Background(), id, timer, func(context.Context) {}, WithLogger(logr.Discard()), WithClock(fc))
+}
+
+func TestRunQueueOrder(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	rq := newRunQueue()
+	a := newQueueTestTask(t, fc, "a", 3*time.Second)
+	b := newQueueTestTask(t, fc, "b", 1*time.Second)
+	c := newQueueTestTask(t, fc, "c", 2*time.Second)
+	rq.push(a)
+	rq.push(b)
+	rq.push(c)
+	if got := rq.peek(); got != b {
+		t.Errorf("peek != b - %s", got.GetID())
+	}
+	b.nextRun.Set(time.Time{})
+	rq.update("b")
+	if got := rq.peek(); got != c {
+		t.Errorf("peek != c after b has no next run - %s", got.GetID())
+	}
+	if !rq.remove("c") || rq.remove("c") {
+		t.Errorf("remove of c did not succeed exactly once")
+	}
+	if got := rq.peek(); got != a {
+		t.Errorf("peek != a - %s", got.GetID())
+	}
+	a.nextRun.Set(time.Time{})
+	rq.update("a")
+	if got := rq.peek(); got != nil {
+		t.Errorf("peek != nil when no Task has a next run - %s", got.GetID())
+	}
+	if rq.Len() != 2 {
+		t.Errorf("Len != 2 - %d", rq.Len())
+	}
+}
+
+func TestRunQueuePriorityUpdate(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	rq := newRunQueue()
+	a := newQueueTestTask(t, fc, "a", 1*time.Second)
+	b := newQueueTestTask(t, fc, "b", 1*time.Second)
+	rq.push(a)
+	rq.push(b)
+	if got := rq.peek(); got != a {
+		t.Errorf("peek != a with equal priority - %s", got.GetID())
+	}
+
+	// A priority changed while queued, as UpdateJob does, only takes effect when the Task is updated
+	done := make(chan struct{})
+	go func() {
+		b.mx.Lock()
+		b.priority = 10
+		b.mx.Unlock()
+		close(done)
+	}()
+	_ = rq.peek()
+	<-done
+	if got := rq.peek(); got != a {
+		t.Errorf("peek != a before b is updated - %s", got.GetID())
+	}
+	rq.update("b")
+	if got := rq.peek(); got != b {
+		t.Errorf("peek != b after its priority was raised - %s", got.GetID())
+	}
+}
+
+func newBenchmarkRunQueue(b *testing.B, fc *clock.Fake, n int) *runQueue {
+	rq := newRunQueue()
+	for i := 0; i < n; i++ {
+		rq.push(newQueueTestTask(b, fc, fmt.Sprintf("task-%d", i), time.Duration(n+i)*time.Millisecond))
+	}
+	return rq
+}
+
+// BenchmarkRunQueueDispatch100k measures taking the next due Task off a queue of 100k Tasks and
+// rescheduling it, the work the scheduler loop does for each dispatch.
+func BenchmarkRunQueueDispatch100k(b *testing.B) {
+	fc := clock.NewFake(testTime)
+	rq := newBenchmarkRunQueue(b, fc, 100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t := rq.peek()
+		fc.Set(t.GetNextRun())
+		next, _ := t.timer.Next()
+		t.nextRun.Set(next)
+		rq.update(t.id)
+	}
+}
+
+// BenchmarkRunQueueAddRemove100k measures adding and removing a Task from a queue of 100k Tasks.
+func BenchmarkRunQueueAddRemove100k(b *testing.B) {
+	fc := clock.NewFake(testTime)
+	rq := newBenchmarkRunQueue(b, fc, 100000)
+	t := newQueueTestTask(b, fc, "extra", 50*time.Second)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rq.push(t)
+		rq.remove(t.id)
+	}
+}
+
+// BenchmarkSchedulerDispatch100k measures the latency from a Task becoming due to its Job running, in a
+// Scheduler with 100k started Tasks.
+func BenchmarkSchedulerDispatch100k(b *testing.B) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	runs := make(chan struct{}, 1)
+	n := 100000
+	for i := 0; i < n; i++ {
+		timer, _ := NewFixed(time.Duration(n+i) * time.Millisecond)
+		if err := s.Add(context.Background(), fmt.Sprintf("task-%d", i), timer, func(context.Context) { runs <- struct{}{} }); err != nil {
+			b.Fatalf("Add Returned Error: %s", err.Error())
+		}
+	}
+	s.StartAll()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		next := s.getNextJob()
+		fc.Set(next.GetNextRun())
+		<-runs
+	}
+	b.StopTimer()
+	s.RemoveAll()
+}
@@ -8,21 +8,35 @@ import (
 
 	"github.com/sasha-s/go-deadlock"
 	"github.com/go-logr/logr"
+	"github.com/Fishwaldo/go-taskmanager/clock"
 	"github.com/Fishwaldo/go-taskmanager/joberrors"
 	schedmetrics "github.com/Fishwaldo/go-taskmanager/metrics"
 	"github.com/armon/go-metrics"
+	"github.com/google/uuid"
 )
 
 // Scheduler manage one or more Schedule creating them using common options, enforcing unique IDs, and supply methods to
 // Start / Stop all schedule(s).
 type Scheduler struct {
 	tasks              map[string]*Task
-	nextRun            timeSlice
+	nextRun            *runQueue
 	mx                 deadlock.RWMutex
 	tsmx               deadlock.RWMutex
 	log                logr.Logger
 	updateScheduleChan chan updateSignalOp
 	scheduleOpts       []Option
+	clock              clock.Clock
+	shutdown           bool
+	cancelMargin       time.Duration
+	quit               chan struct{}
+	loopDone           chan struct{}
+	dispatcher         *dispatcher
+	events             *eventBus
+	store              Store
+	stored             map[string]TaskRecord
+	registry           *JobRegistry
+	restoreOptions     func(rec TaskRecord) []Option
+	locker             Locker
 }
 
 type UpdateSignalOp_Type int
@@ -36,24 +50,9 @@ type updateSignalOp struct {
 	operation UpdateSignalOp_Type
 }
 
-type timeSlice []*Task
-
-func (p timeSlice) Len() int {
-	return len(p)
-}
-
-func (p timeSlice) Less(i, j int) bool {
-	if p[i].GetNextRun().IsZero() {
-		return false
-	}
-	return p[i].GetNextRun().Before(p[j].GetNextRun())
-}
-
-func (p timeSlice) Swap(i, j int) {
-	p[i], p[j] = p[j], p[i]
-}
-
 //NewScheduler Creates new Scheduler, opt Options are applied to *every* schedule added and created by this scheduler.
+//WithPriority, WithRunTimeout, WithRunHardKill, WithHistorySize, WithJitter and WithMisfirePolicy are defaults, used by
+//the Tasks that do not set their own, while Middleware is added after the Middleware of the Task.
 func NewScheduler(opts ...Option) *Scheduler {
 	var options = defaultSchedOptions()
 
@@ -64,34 +63,90 @@ func NewScheduler(opts ...Option) *Scheduler {
 
 	s := &Scheduler{
 		tasks:              make(map[string]*Task),
-		nextRun:            make(timeSlice, 0),
+		nextRun:            newRunQueue(),
 		updateScheduleChan: make(chan updateSignalOp, 100),
 		scheduleOpts:       opts,
 		log:                options.logger,
+		clock:              options.clock,
+		cancelMargin:       options.shutdownCancelMargin,
+		quit:               make(chan struct{}),
+		loopDone:           make(chan struct{}),
+		events:             newEventBus(),
+		store:              options.store,
+		stored:             make(map[string]TaskRecord),
+		registry:           options.registry,
+		restoreOptions:     options.restoreOptions,
+		locker:             options.locker,
+	}
+	if options.maxConcurrentJobs > 0 {
+		s.dispatcher = newDispatcher(options.maxConcurrentJobs, options.maxDispatchWait, options.staleDispatchPolicy, options.priorityAging, options.clock, options.logger)
+	}
+
+	if s.store != nil {
+		recs, err := s.store.Load()
+		if err != nil {
+			s.log.Error(err, "Loading Tasks from Store Failed")
+		}
+		for _, rec := range recs {
+			s.stored[rec.ID] = rec
+		}
 	}
 
 	go s.scheduleLoop()
+	if s.registry != nil {
+		s.restoreJobs()
+	}
 	return s
 }
 
 //Add Create a new Task for` jobFunc func()` that will run according to `timer Timer` with the []Options of the Scheduler.
 func (s *Scheduler) Add(ctx context.Context, id string, timer Timer, job func(context.Context), extraOpts ...Option) error {
+	return s.AddWithError(ctx, id, timer, func(ctx context.Context) error {
+		job(ctx)
+		return nil
+	}, extraOpts...)
+}
+
+//AddWithError Create a new Task for` jobFunc func() error` that will run according to `timer Timer` with the []Options
+//of the Scheduler. Errors returned by the job are passed to the Post Execution and Retry Middleware.
+func (s *Scheduler) AddWithError(ctx context.Context, id string, timer Timer, job func(context.Context) error, extraOpts ...Option) error {
 	s.mx.Lock()
-	defer s.mx.Unlock()
 
+	if s.shutdown {
+		s.mx.Unlock()
+		return joberrors.ErrorSchedulerShutdown{Message: "scheduler has been shut down"}
+	}
 	if _, ok := s.tasks[id]; ok {
+		s.mx.Unlock()
 		return joberrors.ErrorScheduleExists{Message: "job with this id already exists"}
 	}
 
 	// Create schedule
-	opts := append(extraOpts, s.scheduleOpts...)
-	schedule := NewSchedule(ctx, id, timer, job, opts...)
+	schedule := NewScheduleWithError(ctx, id, timer, job, s.taskOptions(extraOpts)...)
 	schedule.updateSignal = s.updateScheduleChan
+	schedule.schedulerDone = s.loopDone
+	schedule.events = s.events
+	schedule.store = s.store
+	rec, restore := s.stored[id]
+	if restore {
+		delete(s.stored, id)
+		if rec.Timer != timerSpec(timer) {
+			// The Timer changed, so the saved next run no longer applies
+			rec.NextRun = time.Time{}
+		}
+		schedule.restore(rec)
+	}
 	// Add to managed schedules
 	s.tasks[id] = schedule
 	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_Jobs), float32(len(s.tasks)))
+	s.mx.Unlock()
+	s.emit(id, Event{Type: EventType_Added})
 
-	s.log.Info("Added New Job", "jobid", schedule.GetID())
+	s.log.Info("Added New Job", "jobid", schedule.GetID(), "restored", restore)
+	if restore && rec.Started {
+		return s.Start(id)
+	}
+	schedule.persist()
 	return nil
 }
 
@@ -99,16 +154,25 @@ func (s *Scheduler) Add(ctx context.Context, id string, timer Timer, job func(co
 func (s *Scheduler) Start(id string) error {
 	s.mx.Lock()
 
+	if s.shutdown {
+		s.mx.Unlock()
+		return joberrors.ErrorSchedulerShutdown{Message: "scheduler has been shut down"}
+	}
 	// Find Schedule by id
 	schedule, found := s.tasks[id]
 	if !found {
+		s.mx.Unlock()
 		return joberrors.ErrorScheduleNotFound{Message: "Schedule Not Found"}
 	}
 
 	// Start it ¯\_(ツ)_/¯
 	schedule.Start()
 	s.mx.Unlock()
-	s.addScheduletoRunQueue(schedule)
+	if !schedule.IsPaused() {
+		s.addScheduletoRunQueue(schedule)
+	}
+	schedule.persist()
+	s.emit(id, Event{Type: EventType_Started})
 	s.log.Info("Start Job", "jobid", schedule.GetID())
 	return nil
 }
@@ -116,9 +180,155 @@ func (s *Scheduler) Start(id string) error {
 //StartAll Start All Schedules managed by the Scheduler
 func (s *Scheduler) StartAll() {
 	s.log.Info("StartAll Called")
-	for _, schedule := range s.tasks {
-		s.Start(schedule.id)
+	for _, id := range s.selectIDs(nil) {
+		s.Start(id)
+	}
+}
+
+//Pause Stop dispatching new runs of the Schedule with the given id, without stopping it. Unlike Stop, Middleware
+//and Timer state is kept and running Jobs are not waited for. Return error if no Schedule with the given id exist.
+func (s *Scheduler) Pause(id string) error {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+	if !schedule.setPaused(true) {
+		return nil
+	}
+	s.tsmx.Lock()
+	found := s.nextRun.remove(id)
+	s.tsmx.Unlock()
+	if s.dispatcher != nil {
+		// Runs waiting for a worker have not been dispatched yet, so put their next run back
+		for _, req := range s.dispatcher.removeScheduled(id) {
+			schedule.nextRun.Set(req.scheduled)
+		}
+	}
+	if found {
+		s.updateScheduleChan <- updateSignalOp{operation: updateSignalOp_Reschedule, id: id}
+	}
+	schedule.persist()
+	s.emit(id, Event{Type: EventType_Paused})
+	s.log.Info("Paused Job", "jobid", id)
+	return nil
+}
+
+//Resume Resume dispatching runs of a Schedule paused with Pause. A run that became due while the Schedule was
+//paused is dispatched straight away. Return error if no Schedule with the given id exist.
+func (s *Scheduler) Resume(id string) error {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return err
+	}
+	if !schedule.setPaused(false) {
+		return nil
+	}
+	if schedule.isStarted() {
+		s.addScheduletoRunQueue(schedule)
+	}
+	schedule.persist()
+	s.emit(id, Event{Type: EventType_Resumed})
+	s.log.Info("Resumed Job", "jobid", id)
+	return nil
+}
+
+//RunNow Run the Schedule with the given id straight away, outside of its Timer's schedule and without changing its
+//next scheduled run. The run goes through the Execution Middleware unless WithRunBypassMiddleware is given, but never
+//the Retry Middleware. Runs are dispatched like scheduled runs, so wait for a free worker if WithMaxConcurrentJobs is
+//set. Returns the ID of the new Job instance, which can be passed to CancelRun straight away. Return error if no
+//Schedule with the given id exist, or the Scheduler has been shut down.
+func (s *Scheduler) RunNow(id string, opts ...RunOption) (string, error) {
+	s.mx.RLock()
+	if s.shutdown {
+		s.mx.RUnlock()
+		return "", joberrors.ErrorSchedulerShutdown{Message: "scheduler has been shut down"}
+	}
+	schedule, found := s.tasks[id]
+	s.mx.RUnlock()
+	if !found {
+		return "", joberrors.ErrorScheduleNotFound{Message: "Schedule Not Found"}
+	}
+
+	runOpts := &runOptions{}
+	for _, opt := range opts {
+		opt.apply(runOpts)
+	}
+	instanceID := uuid.New().String()
+	s.log.Info("Run Job Now", "jobid", id, "instance", instanceID)
+	schedule.addPendingRun(instanceID)
+	if s.dispatcher != nil {
+		if err := s.dispatcher.submitOutOfBand(schedule, instanceID, runOpts); err != nil {
+			schedule.takePendingRun(instanceID)
+			return "", err
+		}
+	} else {
+		go schedule.runOutOfBand(instanceID, runOpts)
+	}
+	return instanceID, nil
+}
+
+//CancelRun Cancel the Context of the running Job instance instanceID (as returned by RunNow) of the Schedule with
+//the given id. The run finishes in the job.CANCELLED State once the Job returns, and is not retried. Return error if
+//no Schedule with the given id exist, or it has no running instance with that id.
+func (s *Scheduler) CancelRun(id string, instanceID string) error {
+	s.mx.RLock()
+	schedule, found := s.tasks[id]
+	s.mx.RUnlock()
+	if !found {
+		return joberrors.ErrorScheduleNotFound{Message: "Schedule Not Found"}
+	}
+	if !schedule.cancelRun(instanceID) {
+		return joberrors.ErrorRunNotFound{Message: "Run Not Found"}
+	}
+	s.log.Info("Cancelled Job Run", "jobid", id, "instance", instanceID)
+	return nil
+}
+
+//CancelAllRuns Cancel the Context of every running Job instance of the Schedule with the given id, returning the
+//IDs of the cancelled instances. The Schedule itself keeps running. Return error if no Schedule with the given id
+//exist.
+func (s *Scheduler) CancelAllRuns(id string) ([]string, error) {
+	s.mx.RLock()
+	schedule, found := s.tasks[id]
+	s.mx.RUnlock()
+	if !found {
+		return nil, joberrors.ErrorScheduleNotFound{Message: "Schedule Not Found"}
+	}
+	ids := schedule.cancelAllRuns()
+	s.log.Info("Cancelled Job Runs", "jobid", id, "instances", ids)
+	return ids, nil
+}
+
+//PauseMatching Pause every Schedule whose Labels match selector, returning their IDs
+func (s *Scheduler) PauseMatching(selector LabelSelector) []string {
+	ids := s.selectIDs(selector)
+	for _, id := range ids {
+		_ = s.Pause(id)
+	}
+	return ids
+}
+
+//ResumeMatching Resume every Schedule whose Labels match selector, returning their IDs
+func (s *Scheduler) ResumeMatching(selector LabelSelector) []string {
+	ids := s.selectIDs(selector)
+	for _, id := range ids {
+		_ = s.Resume(id)
+	}
+	return ids
+}
+
+// selectIDs returns the sorted IDs of the Schedules whose Labels match selector
+func (s *Scheduler) selectIDs(selector LabelSelector) []string {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	ids := make([]string, 0, len(s.tasks))
+	for id, schedule := range s.tasks {
+		if selector.Matches(schedule.GetLabels()) {
+			ids = append(ids, id)
+		}
 	}
+	sort.Strings(ids)
+	return ids
 }
 
 //Stop Stop the Schedule with the given id. Return error if no Schedule with the given id exist.
@@ -129,36 +339,172 @@ func (s *Scheduler) Stop(id string) error {
 	if !found {
 		return joberrors.ErrorScheduleNotFound{Message: "Schedule Not Found"}
 	}
-	s.tsmx.Lock()
-	for pos, sched := range s.nextRun {
-		if sched.id == id {
-			s.nextRun = append(s.nextRun[:pos], s.nextRun[pos+1])
-			break
-		}
-	}
-	s.tsmx.Unlock()
+	s.removeFromRunQueue(id)
 	schedule.Stop()
+	schedule.persist()
+	s.emit(id, Event{Type: EventType_Stopped})
 	return nil
 }
 
+//Remove Stop the Schedule with the given id, cancel the Context of any of its running Jobs, wait for them to
+//finish and remove the Schedule from the Scheduler. Return error if no Schedule with the given id exist.
+func (s *Scheduler) Remove(id string) error {
+	s.mx.Lock()
+	schedule, found := s.tasks[id]
+	if !found {
+		s.mx.Unlock()
+		return joberrors.ErrorScheduleNotFound{Message: "Schedule Not Found"}
+	}
+	delete(s.tasks, id)
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_Jobs), float32(len(s.tasks)))
+	s.mx.Unlock()
+
+	s.removeFromRunQueue(id)
+	schedule.remove()
+	s.deleteFromStore(id)
+	s.emit(id, Event{Type: EventType_Removed})
+	s.log.Info("Removed Job", "jobid", id)
+	return nil
+}
+
+//RemoveAll Removes All Schedules managed by the Scheduler concurrently, but will block until ALL of them have been
+//removed.
+func (s *Scheduler) RemoveAll() {
+	s.mx.Lock()
+	tasks := s.tasks
+	s.tasks = make(map[string]*Task)
+	metrics.SetGauge(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_Jobs), 0)
+	s.mx.Unlock()
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(tasks))
+	for id, schedule := range tasks {
+		s.removeFromRunQueue(id)
+		go func(scheduleCpy *Task) {
+			scheduleCpy.remove()
+			s.deleteFromStore(scheduleCpy.id)
+			s.emit(scheduleCpy.id, Event{Type: EventType_Removed})
+			wg.Done()
+		}(schedule)
+	}
+	wg.Wait()
+	s.log.Info("Removed All Jobs")
+}
+
 //StopAll Stops All Schedules managed by the Scheduler concurrently, but will block until ALL of them have stopped.
+//Unlike Stop, the Started state saved in the Store (see WithStore) is kept, so the Schedules are Started again when
+//they are next added.
 func (s *Scheduler) StopAll() {
 	s.mx.Lock()
 	defer s.mx.Unlock()
 	s.tsmx.Lock()
-	s.nextRun = nil
+	s.nextRun = newRunQueue()
 	s.tsmx.Unlock()
+	if s.dispatcher != nil {
+		s.dispatcher.clear()
+	}
 	wg := sync.WaitGroup{}
 	wg.Add(len(s.tasks))
 	for _, schedule := range s.tasks {
 		go func(scheduleCpy *Task) {
 			scheduleCpy.Stop()
+			s.emit(scheduleCpy.id, Event{Type: EventType_Stopped})
 			wg.Done()
 		}(schedule)
 	}
 	wg.Wait()
 }
 
+//Shutdown Gracefully stops the Scheduler. No new Jobs are dispatched, and Shutdown waits for running Jobs to finish.
+//If ctx has a deadline, the Context of running Jobs is canceled shortly before it (see WithShutdownCancelMargin),
+//otherwise when ctx is done. Once the Jobs have finished or ctx is done, the scheduler loop exits. If Jobs were still
+//running when ctx was done, a joberrors.ErrorShutdownIncomplete listing them is returned.
+//A Scheduler can not be restarted after Shutdown.
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mx.Lock()
+	if s.shutdown {
+		s.mx.Unlock()
+		return joberrors.ErrorSchedulerShutdown{Message: "scheduler has already been shut down"}
+	}
+	s.shutdown = true
+	tasks := make([]*Task, 0, len(s.tasks))
+	for _, schedule := range s.tasks {
+		tasks = append(tasks, schedule)
+	}
+	s.mx.Unlock()
+
+	// Stop Dispatching
+	s.log.Info("Shutting Down Scheduler")
+	s.tsmx.Lock()
+	s.nextRun = newRunQueue()
+	s.tsmx.Unlock()
+	if s.dispatcher != nil {
+		s.dispatcher.stop()
+	}
+	for _, schedule := range tasks {
+		schedule.stopSchedule()
+	}
+
+	jobsDone := make(chan struct{})
+	go func() {
+		for _, schedule := range tasks {
+			schedule.wg.Wait()
+		}
+		close(jobsDone)
+	}()
+
+	// Context deadlines are always wall clock time, so dont use s.clock here
+	var cancelChan <-chan time.Time
+	if deadline, ok := ctx.Deadline(); ok {
+		cancelChan = time.After(time.Until(deadline) - s.cancelMargin)
+	}
+
+	var err error
+	canceled := false
+	cancelJobs := func() {
+		if !canceled {
+			s.log.Info("Canceling Running Jobs")
+			for _, schedule := range tasks {
+				schedule.cancel()
+			}
+			canceled = true
+		}
+	}
+wait:
+	for {
+		select {
+		case <-jobsDone:
+			break wait
+		case <-cancelChan:
+			cancelJobs()
+			cancelChan = nil
+		case <-ctx.Done():
+			cancelJobs()
+			running := make(map[string][]string)
+			for _, schedule := range tasks {
+				if ids := schedule.activeJobs.ids(); len(ids) > 0 {
+					running[schedule.id] = ids
+				}
+			}
+			if len(running) > 0 {
+				s.log.Info("Jobs Still Running at Shutdown", "jobs", running)
+				err = joberrors.ErrorShutdownIncomplete{Message: "jobs still running at shutdown", Running: running, Err: ctx.Err()}
+			}
+			break wait
+		}
+	}
+
+	// End the scheduler loop
+	close(s.quit)
+	<-s.loopDone
+	for _, schedule := range tasks {
+		metrics.SetGaugeWithLabels(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_Up), 0, []metrics.Label{{Name: "id", Value: schedule.id}})
+	}
+	s.events.close()
+	s.log.Info("Scheduler Shutdown")
+	return err
+}
+
 //GetSchedule Returns a Schedule by ID from the Scheduler
 func (s *Scheduler) GetSchedule(id string) (*Task, error) {
 	s.mx.Lock()
@@ -170,27 +516,32 @@ func (s *Scheduler) GetSchedule(id string) (*Task, error) {
 	return j, nil
 }
 
-//GetAllSchedules Returns all Schedule's in the Scheduler
+//History Returns the last runs of the Schedule with the given id, newest first, see WithHistorySize. Return error
+//if no Schedule with the given id exist.
+func (s *Scheduler) History(id string) ([]RunRecord, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.History(), nil
+}
+
+//GetAllSchedules Returns a copy of the map of all Schedule's in the Scheduler, see List for a read only snapshot
+//of their state
 func (s *Scheduler) GetAllSchedules() (map[string]*Task, error) {
-	s.mx.Lock()
-	defer s.mx.Unlock()
-	return s.tasks, nil
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	tasks := make(map[string]*Task, len(s.tasks))
+	for id, schedule := range s.tasks {
+		tasks[id] = schedule
+	}
+	return tasks, nil
 }
 
 func (s *Scheduler) getNextJob() *Task {
 	s.tsmx.RLock()
 	defer s.tsmx.RUnlock()
-	if s.nextRun.Len() == 0 {
-		return nil
-	}
-	for _, sched := range s.nextRun {
-		if sched.nextRun.Get().IsZero() {
-			s.log.Info("NextRun is Zero", "jobid", sched.GetID())
-			continue
-		}
-		return sched
-	}
-	return nil
+	return s.nextRun.peek()
 }
 
 func (s *Scheduler) scheduleLoop() {
@@ -200,26 +551,32 @@ func (s *Scheduler) scheduleLoop() {
 		nextjob := s.getNextJob()
 		if nextjob != nil {
 			nextRun = nextjob.GetNextRun()
-			s.log.Info("Next Scheduler Run", "next", time.Until(nextRun), "jobid", nextjob.GetID())
-			nextRunChan = time.After(time.Until(nextRun))
+			s.log.V(1).Info("Next Scheduler Run", "next", nextRun.Sub(s.clock.Now()), "jobid", nextjob.GetID())
+			nextRunChan = s.clock.After(nextRun.Sub(s.clock.Now()))
 		} else {
-			s.log.Info("No Jobs Scheduled")
+			s.log.V(1).Info("No Jobs Scheduled")
+			nextRunChan = nil
 		}
 
 		select {
+		case <-s.quit:
+			s.log.Info("Scheduler Loop Exiting")
+			close(s.loopDone)
+			return
 		case <-nextRunChan:
-			if nextjob != nil {
+			if nextjob != nil && s.isQueued(nextjob) {
 				s.log.Info("Dispatching Job", "jobid", nextjob.id)
 				nextjob.nextRun.Set(time.Time{})
-				go nextjob.Run()
+				s.updateNextRun(nextjob.id)
+				s.dispatchDue(nextjob, nextRun)
 			} else {
-				s.log.Error(nil, "nextjob is Nil")
+				s.log.Error(nil, "nextjob is Nil or no longer Scheduled")
 			}
 		case op := <-s.updateScheduleChan:
 			switch op.operation {
 			case updateSignalOp_Reschedule:
-				s.log.Info("recalcSchedule Triggered", "operation", op.id)
-				s.updateNextRun()
+				s.log.V(1).Info("recalcSchedule Triggered", "operation", op.id)
+				s.updateNextRun(op.id)
 			default:
 				s.log.Error(nil, "Unhandled updateSignalOp Recieved")
 			}
@@ -228,22 +585,87 @@ func (s *Scheduler) scheduleLoop() {
 
 }
 
-func (s *Scheduler) updateNextRun() {
+// dispatch runs schedule for the run scheduled for scheduled. A catch up run, dispatched for a misfire, does not
+// reschedule the Task.
+func (s *Scheduler) dispatch(schedule *Task, scheduled time.Time, catchUp bool) {
+	if s.locker != nil {
+		// Claim the run in the background, so a slow Locker does not hold up the other Tasks
+		go func() {
+			if s.claim(schedule, scheduled, catchUp) {
+				s.dispatchClaimed(schedule, scheduled, catchUp)
+			}
+		}()
+		return
+	}
+	s.dispatchClaimed(schedule, scheduled, catchUp)
+}
+
+func (s *Scheduler) dispatchClaimed(schedule *Task, scheduled time.Time, catchUp bool) {
+	s.emit(schedule.id, Event{Type: EventType_Dispatched, Scheduled: scheduled})
+	if s.dispatcher != nil {
+		s.dispatcher.submit(schedule, scheduled, catchUp)
+		return
+	}
+	go schedule.runScheduled(scheduled, catchUp)
+}
+
+// taskOptions returns the Options to create a Task given extraOpts with: the Options of the Scheduler that are defaults
+// for its Tasks, then extraOpts, which override them, then the other Options of the Scheduler
+func (s *Scheduler) taskOptions(extraOpts []Option) []Option {
+	opts := make([]Option, 0, len(extraOpts)+len(s.scheduleOpts))
+	for _, option := range s.scheduleOpts {
+		if _, ok := option.(taskDefaultOption); ok {
+			opts = append(opts, option)
+		}
+	}
+	opts = append(opts, extraOpts...)
+	for _, option := range s.scheduleOpts {
+		if _, ok := option.(taskDefaultOption); !ok {
+			opts = append(opts, option)
+		}
+	}
+	return opts
+}
+
+func (s *Scheduler) updateNextRun(id string) {
 	s.tsmx.Lock()
 	defer s.tsmx.Unlock()
-	sort.Sort(s.nextRun)
-	for _, job := range s.nextRun {
-		s.log.Info("Next Run", "jobid", job.GetID(), "when", job.GetNextRun().Format(time.RFC1123))
-	}
+	s.nextRun.update(id)
 }
 
 func (s *Scheduler) addScheduletoRunQueue(schedule *Task) {
 	s.tsmx.Lock()
-	defer s.tsmx.Unlock()
-	s.nextRun = append(s.nextRun, schedule)
-	s.log.Info("addScheduletoRunQueue", "jobid", schedule.GetID())
-	for _, job := range s.nextRun {
-		s.log.Info("Job Run Queue", "jobid", job.GetID(), "when", job.GetNextRun().Format(time.RFC1123))
-	}
+	s.nextRun.push(schedule)
+	s.tsmx.Unlock()
+	s.log.V(1).Info("addScheduletoRunQueue", "jobid", schedule.GetID(), "when", schedule.GetNextRun())
 	s.updateScheduleChan <- updateSignalOp{operation: updateSignalOp_Reschedule, id: schedule.id}
 }
+
+func (s *Scheduler) removeFromRunQueue(id string) {
+	s.tsmx.Lock()
+	found := s.nextRun.remove(id)
+	s.tsmx.Unlock()
+	if s.dispatcher != nil {
+		s.dispatcher.remove(id)
+	}
+	if found {
+		s.updateScheduleChan <- updateSignalOp{operation: updateSignalOp_Reschedule, id: id}
+	}
+}
+
+// deleteFromStore deletes the TaskRecord of the Task with the given id from the Store, if any
+func (s *Scheduler) deleteFromStore(id string) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Delete(id); err != nil {
+		s.log.Error(err, "Deleting Task from Store Failed", "jobid", id)
+	}
+}
+
+func (s *Scheduler) isQueued(schedule *Task) bool {
+	s.tsmx.RLock()
+	defer s.tsmx.RUnlock()
+	t, ok := s.nextRun.get(schedule.id)
+	return ok && t == schedule
+}
@@ -0,0 +1,744 @@
+package taskmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/Fishwaldo/go-taskmanager/job"
+	"github.com/Fishwaldo/go-taskmanager/joberrors"
+	"github.com/go-logr/logr"
+)
+
+func waitForRun(t *testing.T, runs chan string, want string) {
+	t.Helper()
+	select {
+	case got := <-runs:
+		if got != want {
+			t.Errorf("Expected %s to run, got %s", want, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for %s to run", want)
+	}
+}
+
+func expectNoRun(t *testing.T, runs chan string) {
+	t.Helper()
+	select {
+	case got := <-runs:
+		t.Errorf("Unexpected run of %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func newTestScheduler(t *testing.T) (*Scheduler, *clock.Fake, chan string) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	runs := make(chan string, 10)
+	for _, tc := range []struct {
+		id    string
+		every time.Duration
+	}{{"three", 3 * time.Second}, {"one", 1 * time.Second}, {"two", 2 * time.Second}} {
+		id := tc.id
+		timer, _ := NewOnce(tc.every)
+		if err := s.Add(context.Background(), id, timer, func(ctx context.Context) { runs <- id }); err != nil {
+			t.Fatalf("Add Returned Error: %s", err.Error())
+		}
+	}
+	return s, fc, runs
+}
+
+func TestSchedulerFakeClockOrder(t *testing.T) {
+	s, fc, runs := newTestScheduler(t)
+	s.StartAll()
+
+	expectNoRun(t, runs)
+	for _, want := range []string{"one", "two", "three"} {
+		fc.Advance(1 * time.Second)
+		waitForRun(t, runs, want)
+	}
+	fc.Advance(10 * time.Second)
+	expectNoRun(t, runs)
+}
+
+func TestSchedulerRemove(t *testing.T) {
+	s, fc, runs := newTestScheduler(t)
+	s.StartAll()
+
+	if err := s.Remove("two"); err != nil {
+		t.Errorf("Remove Returned Error: %s", err.Error())
+	}
+	if err := s.Remove("two"); err == nil {
+		t.Errorf("Remove of a removed Task did not return an Error")
+	}
+	if _, err := s.GetSchedule("two"); err == nil {
+		t.Errorf("GetSchedule found a removed Task")
+	}
+	fc.Advance(1 * time.Second)
+	waitForRun(t, runs, "one")
+	fc.Advance(1 * time.Second)
+	expectNoRun(t, runs)
+	fc.Advance(1 * time.Second)
+	waitForRun(t, runs, "three")
+
+	s.RemoveAll()
+	if all, _ := s.GetAllSchedules(); len(all) != 0 {
+		t.Errorf("RemoveAll left %d Tasks", len(all))
+	}
+}
+
+func TestSchedulerRemoveRunning(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	defer s.Shutdown(context.Background())
+	started := make(chan struct{}, 1)
+	var finished int32
+	timer, _ := NewFixed(1 * time.Second)
+	_ = s.Add(context.Background(), "running", timer, func(ctx context.Context) {
+		started <- struct{}{}
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&finished, 1)
+	})
+	_ = s.Start("running")
+	task, _ := s.GetSchedule("running")
+	fc.Advance(1 * time.Second)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the Job to start")
+	}
+
+	if err := s.Remove("running"); err != nil {
+		t.Fatalf("Remove Returned Error: %s", err.Error())
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Errorf("Remove returned before the running Job finished")
+	}
+	if s.isQueued(task) {
+		t.Errorf("The removed Task is still in the run queue")
+	}
+	fc.Advance(1 * time.Second)
+	select {
+	case <-started:
+		t.Errorf("The removed Task ran again")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// schedulertestemw is a Execution Middleware of another type than testemw, to tell them apart
+type schedulertestemw struct {
+	testemw
+}
+
+func TestSchedulerOptionOrder(t *testing.T) {
+	s := NewScheduler(WithLogger(logr.Discard()), WithExecutationMiddleWare(&schedulertestemw{}))
+	defer s.Shutdown(context.Background())
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "export", timer, func(ctx context.Context) {}, WithExecutationMiddleWare(&testemw{}))
+
+	// The Options of the Task are applied before those of the Scheduler
+	info, _ := s.Describe("export")
+	want := []string{"*taskmanager.testemw", "*taskmanager.schedulertestemw"}
+	if len(info.Middlewares) != 2 || info.Middlewares[0] != want[0] || info.Middlewares[1] != want[1] {
+		t.Errorf("Middleware is %v, not %v", info.Middlewares, want)
+	}
+}
+
+func TestSchedulerTaskDefaults(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc), WithRunTimeout(1*time.Hour), WithPriority(5), WithHistorySize(3),
+		WithJitter(1*time.Minute), WithMisfirePolicy(MisfirePolicy_Skip, 1*time.Minute, 0))
+	defer s.Shutdown(context.Background())
+	deadlines := make(chan time.Time, 2)
+	job := func(ctx context.Context) {
+		deadline, _ := ctx.Deadline()
+		deadlines <- deadline
+	}
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "own", timer, job, WithRunTimeout(1*time.Minute), WithPriority(1), WithHistorySize(0),
+		WithJitter(0), WithMisfirePolicy(MisfirePolicy_RunAll, 2*time.Minute, 2))
+	timer2, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "default", timer2, job)
+
+	// The Options of a Task override the defaults given to the Scheduler
+	for _, tc := range []struct {
+		id          string
+		timeout     time.Duration
+		priority    int
+		historySize int
+		jitter      time.Duration
+		policy      MisfirePolicy
+	}{{"own", 1 * time.Minute, 1, 0, 0, MisfirePolicy_RunAll}, {"default", 1 * time.Hour, 5, 3, 1 * time.Minute, MisfirePolicy_Skip}} {
+		schedule, _ := s.GetSchedule(tc.id)
+		schedule.mx.RLock()
+		priority, jitter, policy := schedule.priority, schedule.jitter, schedule.misfirePolicy
+		schedule.mx.RUnlock()
+		if priority != tc.priority || jitter != tc.jitter || policy != tc.policy || schedule.history.capacity() != tc.historySize {
+			t.Errorf("%s has priority %d, jitter %s, misfire policy %s and history size %d", tc.id, priority, jitter, policy, schedule.history.capacity())
+		}
+		if _, err := s.RunNow(tc.id, WithRunBypassMiddleware()); err != nil {
+			t.Fatalf("RunNow Returned Error: %s", err.Error())
+		}
+		select {
+		case deadline := <-deadlines:
+			if !deadline.Equal(testTime.Add(tc.timeout)) {
+				t.Errorf("%s ran with a deadline of %s, not %s", tc.id, deadline, testTime.Add(tc.timeout))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s to run", tc.id)
+		}
+	}
+}
+
+func TestSchedulerShutdown(t *testing.T) {
+	s := NewScheduler(WithLogger(logr.Discard()), WithShutdownCancelMargin(150*time.Millisecond))
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	timer, _ := NewOnce(0)
+	_ = s.Add(context.Background(), "cancels", timer, func(ctx context.Context) {
+		started <- struct{}{}
+		<-ctx.Done()
+	})
+	timer2, _ := NewOnce(0)
+	_ = s.Add(context.Background(), "ignores", timer2, func(ctx context.Context) {
+		started <- struct{}{}
+		<-release
+	})
+	s.StartAll()
+	<-started
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	var incomplete joberrors.ErrorShutdownIncomplete
+	if !errors.As(err, &incomplete) {
+		t.Fatalf("Shutdown did not return ErrorShutdownIncomplete - %v", err)
+	}
+	if _, ok := incomplete.Running["cancels"]; ok {
+		t.Errorf("Shutdown reported a canceled Job as running")
+	}
+	if len(incomplete.Running["ignores"]) != 1 {
+		t.Errorf("Shutdown did not report the running Job - %v", incomplete.Running)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown error does not wrap context.DeadlineExceeded")
+	}
+	close(release)
+
+	if err := s.Add(context.Background(), "late", timer, func(ctx context.Context) {}); err == nil {
+		t.Errorf("Add after Shutdown did not return an error")
+	}
+	if err := s.Shutdown(context.Background()); err == nil {
+		t.Errorf("Second Shutdown did not return an error")
+	}
+}
+
+func TestSchedulerMaxConcurrentJobs(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc), WithMaxConcurrentJobs(1), WithMaxDispatchWait(5*time.Second, StaleDispatch_Drop))
+	runs := make(chan string, 10)
+	release := make(chan struct{})
+	for _, id := range []string{"a", "b", "c"} {
+		id := id
+		timer, _ := NewOnce(1 * time.Second)
+		_ = s.Add(context.Background(), id, timer, func(ctx context.Context) {
+			runs <- id
+			<-release
+		})
+	}
+	_ = s.Start("a")
+	fc.Advance(1 * time.Second)
+	waitForRun(t, runs, "a")
+
+	// b is queued behind a, and runs once a finishes
+	_ = s.Start("b")
+	fc.Advance(1 * time.Second)
+	expectNoRun(t, runs)
+	release <- struct{}{}
+	waitForRun(t, runs, "b")
+
+	// c waits longer than the max dispatch wait and is dropped
+	_ = s.Start("c")
+	fc.Advance(1 * time.Second)
+	expectNoRun(t, runs)
+	fc.Advance(10 * time.Second)
+	release <- struct{}{}
+	expectNoRun(t, runs)
+	if c, _ := s.GetSchedule("c"); !c.GetNextRun().IsZero() {
+		t.Errorf("Dropped Once Task has a next run - %s", c.GetNextRun())
+	}
+}
+
+func TestSchedulerPauseResume(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	runs := make(chan string, 10)
+	for _, id := range []string{"billing", "cleanup"} {
+		id := id
+		timer, _ := NewFixed(1 * time.Second)
+		_ = s.Add(context.Background(), id, timer, func(ctx context.Context) { runs <- id }, WithLabels(map[string]string{"job": id}))
+	}
+	_ = s.Start("billing")
+	_ = s.Start("cleanup")
+
+	sel, err := ParseLabelSelector("job=cleanup")
+	if err != nil {
+		t.Fatalf("ParseLabelSelector Returned Error: %s", err.Error())
+	}
+	if paused := s.PauseMatching(sel); len(paused) != 1 || paused[0] != "cleanup" {
+		t.Errorf("PauseMatching paused %v", paused)
+	}
+	cleanup, _ := s.GetSchedule("cleanup")
+	if !cleanup.IsPaused() {
+		t.Errorf("cleanup is not Paused")
+	}
+	fc.Advance(1 * time.Second)
+	waitForRun(t, runs, "billing")
+	expectNoRun(t, runs)
+
+	if err := s.Resume("cleanup"); err != nil {
+		t.Errorf("Resume Returned Error: %s", err.Error())
+	}
+	waitForRun(t, runs, "cleanup")
+	if err := s.Pause("missing"); err == nil {
+		t.Errorf("Pause of a missing Task did not return an error")
+	}
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	sel, err := ParseLabelSelector("env=prod, tier=web")
+	if err != nil {
+		t.Fatalf("ParseLabelSelector Returned Error: %s", err.Error())
+	}
+	if !sel.Matches(map[string]string{"env": "prod", "tier": "web", "team": "a"}) {
+		t.Errorf("Selector %s did not match", sel)
+	}
+	if sel.Matches(map[string]string{"env": "prod"}) {
+		t.Errorf("Selector %s matched labels missing tier", sel)
+	}
+	if _, err := ParseLabelSelector("env"); err == nil {
+		t.Errorf("ParseLabelSelector accepted a term without a value")
+	}
+}
+
+func TestSchedulerRunNow(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	runs := make(chan string, 10)
+	runJob := func(ctx context.Context) {
+		j, _ := ctx.Value(job.JobCtxValue{}).(*job.Job)
+		runs <- j.ID()
+	}
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "export", timer, runJob)
+	_ = s.Start("export")
+	export, _ := s.GetSchedule("export")
+	next := export.GetNextRun()
+
+	id, err := s.RunNow("export")
+	if err != nil {
+		t.Fatalf("RunNow Returned Error: %s", err.Error())
+	}
+	waitForRun(t, runs, id)
+	if !export.GetNextRun().Equal(next) {
+		t.Errorf("RunNow changed the next run from %s to %s", next, export.GetNextRun())
+	}
+
+	// testemw cancels every run, unless it is bypassed
+	timer2, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "blocked", timer2, runJob, WithExecutationMiddleWare(&testemw{}))
+	if _, err := s.RunNow("blocked"); err != nil {
+		t.Fatalf("RunNow Returned Error: %s", err.Error())
+	}
+	expectNoRun(t, runs)
+	id, _ = s.RunNow("blocked", WithRunBypassMiddleware())
+	waitForRun(t, runs, id)
+
+	if _, err := s.RunNow("missing"); err == nil {
+		t.Errorf("RunNow of a missing Task did not return an error")
+	}
+}
+
+func TestSchedulerCancelRun(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	runs := make(chan string, 10)
+	results := make(chan error, 10)
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.AddWithError(context.Background(), "export", timer, func(ctx context.Context) error {
+		j, _ := ctx.Value(job.JobCtxValue{}).(*job.Job)
+		runs <- j.ID()
+		<-ctx.Done()
+		results <- ctx.Err()
+		return ctx.Err()
+	})
+
+	id, _ := s.RunNow("export")
+	waitForRun(t, runs, id)
+	if err := s.CancelRun("export", "missing"); !errors.As(err, &joberrors.ErrorRunNotFound{}) {
+		t.Errorf("CancelRun of a missing run returned %v", err)
+	}
+	if err := s.CancelRun("export", id); err != nil {
+		t.Fatalf("CancelRun Returned Error: %s", err.Error())
+	}
+	if err := <-results; !errors.Is(err, context.Canceled) {
+		t.Errorf("Job Context returned %v, not context.Canceled", err)
+	}
+
+	id1, _ := s.RunNow("export")
+	id2, _ := s.RunNow("export")
+	<-runs
+	<-runs
+	ids, err := s.CancelAllRuns("export")
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("CancelAllRuns returned %v, %v", ids, err)
+	}
+	for _, id := range []string{id1, id2} {
+		if id != ids[0] && id != ids[1] {
+			t.Errorf("CancelAllRuns did not cancel %s", id)
+		}
+	}
+	<-results
+	<-results
+
+	if _, err := s.CancelAllRuns("missing"); err == nil {
+		t.Errorf("CancelAllRuns of a missing Task did not return an error")
+	}
+}
+
+func TestSchedulerCancelQueuedRun(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc), WithMaxConcurrentJobs(1))
+	runs := make(chan string, 10)
+	release := make(chan struct{})
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "export", timer, func(ctx context.Context) {
+		j, _ := ctx.Value(job.JobCtxValue{}).(*job.Job)
+		runs <- j.ID()
+		<-release
+	})
+
+	// The first run holds the only worker, so the second is still queued when it is canceled
+	first, _ := s.RunNow("export")
+	waitForRun(t, runs, first)
+	queued, err := s.RunNow("export")
+	if err != nil {
+		t.Fatalf("RunNow Returned Error: %s", err.Error())
+	}
+	if err := s.CancelRun("export", queued); err != nil {
+		t.Fatalf("CancelRun of a queued run Returned Error: %s", err.Error())
+	}
+	if err := s.CancelRun("export", queued); !errors.As(err, &joberrors.ErrorRunNotFound{}) {
+		t.Errorf("Second CancelRun of a queued run returned %v", err)
+	}
+	close(release)
+	expectNoRun(t, runs)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		history, _ := s.History("export")
+		if len(history) == 2 {
+			if history[0].InstanceID != queued || history[0].State != job.CANCELLED {
+				t.Errorf("Canceled run recorded as %+v", history[0])
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the canceled run, History %+v", history)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Runs can not be queued once the dispatcher has stopped
+	s.dispatcher.stop()
+	if _, err := s.RunNow("export"); !errors.As(err, &joberrors.ErrorSchedulerShutdown{}) {
+		t.Errorf("RunNow with a stopped dispatcher returned %v", err)
+	}
+}
+
+func TestSchedulerDescribe(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	testErr := errors.New("test error")
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.AddWithError(context.Background(), "export", timer, func(ctx context.Context) error { return testErr }, WithExecutationMiddleWare(&testemw{}))
+	timer2, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "backup", timer2, func(ctx context.Context) {})
+
+	info, err := s.Describe("export")
+	if err != nil {
+		t.Fatalf("Describe Returned Error: %s", err.Error())
+	}
+	if info.State != TaskState_Stopped || info.LastOutcome != RunOutcome_None || info.Runs != 0 {
+		t.Errorf("New Task Described as %+v", info)
+	}
+	if len(info.Middlewares) != 1 || info.Middlewares[0] != "*taskmanager.testemw" {
+		t.Errorf("Middlewares are %v", info.Middlewares)
+	}
+	if !info.NextRun.Equal(testTime.Add(1 * time.Hour)) {
+		t.Errorf("NextRun is %s", info.NextRun)
+	}
+
+	_ = s.Start("export")
+	_ = s.Pause("backup")
+	_ = s.Start("backup")
+	if _, err := s.RunNow("export", WithRunBypassMiddleware()); err != nil {
+		t.Fatalf("RunNow Returned Error: %s", err.Error())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for info, _ = s.Describe("export"); info.LastOutcome == RunOutcome_None || info.State == TaskState_Running; info, _ = s.Describe("export") {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the run to finish")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if info.State != TaskState_Scheduled || info.LastOutcome != RunOutcome_Failed || info.Runs != 1 || info.Failures != 1 {
+		t.Errorf("Task Described as %+v after a failed run", info)
+	}
+	if info.LastError == "" || !info.LastStart.Equal(testTime) || !info.LastFinish.Equal(testTime) {
+		t.Errorf("Last run Described as %+v", info)
+	}
+
+	list := s.List()
+	if len(list) != 2 || list[0].ID != "backup" || list[1].ID != "export" {
+		t.Fatalf("List returned %+v", list)
+	}
+	if list[0].State != TaskState_Paused {
+		t.Errorf("Paused Task State is %s", list[0].State)
+	}
+	if _, err := s.Describe("missing"); err == nil {
+		t.Errorf("Describe of a missing Task did not return an error")
+	}
+}
+
+func TestSchedulerHistory(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	testErr := errors.New("test error")
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.AddWithError(context.Background(), "export", timer, func(ctx context.Context) error { return testErr }, WithExecutationMiddleWare(&testemw{}), WithHistorySize(2))
+	waitForHistory := func(n int) []RunRecord {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			history, err := s.History("export")
+			if err != nil {
+				t.Fatalf("History Returned Error: %s", err.Error())
+			}
+			if len(history) >= n {
+				return history
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %d runs in the History", n)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// testemw cancels the run
+	rejected, _ := s.RunNow("export")
+	history := waitForHistory(1)
+	if history[0].InstanceID != rejected || history[0].State != job.NEW || !history[0].OutOfBand {
+		t.Errorf("Rejected run recorded as %+v", history[0])
+	}
+	if len(history[0].Decisions) != 1 || history[0].Decisions[0].Stage != MiddlewareStage_PreExecution || history[0].Decisions[0].Result != "CANCEL" {
+		t.Errorf("Rejected run Decisions are %+v", history[0].Decisions)
+	}
+
+	first, _ := s.RunNow("export", WithRunBypassMiddleware())
+	waitForHistory(2)
+	second, _ := s.RunNow("export", WithRunBypassMiddleware())
+	deadline := time.Now().Add(5 * time.Second)
+	for history = waitForHistory(2); history[0].InstanceID != second; history = waitForHistory(2) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s in the History", second)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if len(history) != 2 || history[1].InstanceID != first {
+		t.Fatalf("History is %+v, expected %s then %s", history, second, first)
+	}
+	if history[0].State != job.FAILED || history[0].Error == "" || !history[0].Start.Equal(testTime) {
+		t.Errorf("Failed run recorded as %+v", history[0])
+	}
+
+	if _, err := s.History("missing"); err == nil {
+		t.Errorf("History of a missing Task did not return an error")
+	}
+}
+
+func TestSchedulerSubscribe(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	events := s.Subscribe(EventTaskIDs("export"))
+	// never read, so must not block anything
+	_ = s.Subscribe(nil)
+
+	timer, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "export", timer, func(ctx context.Context) {})
+	timer2, _ := NewFixed(1 * time.Hour)
+	_ = s.Add(context.Background(), "backup", timer2, func(ctx context.Context) {})
+	_ = s.Start("export")
+	for i := 0; i < eventBufferSize; i++ {
+		_ = s.Pause("backup")
+		_ = s.Resume("backup")
+	}
+	id, _ := s.RunNow("export", WithRunBypassMiddleware())
+
+	for _, want := range []EventType{EventType_Added, EventType_Started, EventType_RunStarted, EventType_RunFinished} {
+		select {
+		case e := <-events:
+			if e.Type != want || e.TaskID != "export" {
+				t.Errorf("Expected a %s Event for export, got %+v", want, e)
+			}
+			if (want == EventType_RunStarted || want == EventType_RunFinished) && e.InstanceID != id {
+				t.Errorf("Expected a %s Event for %s, got %+v", want, id, e)
+			}
+			if want == EventType_RunFinished && e.State != job.FINISHED {
+				t.Errorf("RunFinished Event State is %s", e.State)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for a %s Event", want)
+		}
+	}
+
+	if !s.Unsubscribe(events) {
+		t.Errorf("Unsubscribe returned false")
+	}
+	if _, ok := <-events; ok {
+		t.Errorf("Events channel was not closed by Unsubscribe")
+	}
+	if s.Unsubscribe(events) {
+		t.Errorf("Unsubscribe of a closed channel returned true")
+	}
+}
+
+func TestSchedulerAddJob(t *testing.T) {
+	s := NewScheduler(WithLogger(logr.Discard()))
+	if err := s.AddJob(context.Background(), "export", "@every 1h", "noop", nil); !errors.As(err, &joberrors.ErrorJobTypeNotFound{}) {
+		t.Errorf("AddJob without a JobRegistry returned %v", err)
+	}
+
+	registry := NewJobRegistry()
+	noop := func(params json.RawMessage) (func(context.Context) error, error) {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+	_ = registry.Register("noop", noop)
+	if err := registry.Register("noop", noop); !errors.As(err, &joberrors.ErrorJobTypeExists{}) {
+		t.Errorf("Registering a job type twice returned %v", err)
+	}
+	s = NewScheduler(WithLogger(logr.Discard()), WithJobRegistry(registry))
+	if err := s.AddJob(context.Background(), "export", "@every 1h", "missing", nil); !errors.As(err, &joberrors.ErrorJobTypeNotFound{}) {
+		t.Errorf("AddJob of a missing job type returned %v", err)
+	}
+	if err := s.AddJob(context.Background(), "export", "@every one hour", "noop", nil); err == nil {
+		t.Errorf("AddJob with a invalid timer spec did not return an error")
+	}
+	if err := s.AddJob(context.Background(), "export", "0 3 * * *", "noop", nil); err != nil {
+		t.Fatalf("AddJob Returned Error: %s", err.Error())
+	}
+	if info, _ := s.Describe("export"); info.JobType != "noop" {
+		t.Errorf("JobType is %q", info.JobType)
+	}
+}
+
+func TestSchedulerMisfirePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy MisfirePolicy
+		runs   int
+	}{{MisfirePolicy_Skip, 0}, {MisfirePolicy_RunOnce, 1}, {MisfirePolicy_RunAll, 2}} {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			fc := clock.NewFake(testTime)
+			s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+			defer s.Shutdown(context.Background())
+			events := s.Subscribe(EventTypes(EventType_Misfired))
+			runs := make(chan string, 10)
+			timer, _ := NewCron("0 * * * *")
+			_ = s.Add(context.Background(), "hourly", timer, func(ctx context.Context) { runs <- "hourly" }, WithMisfirePolicy(tc.policy, 5*time.Minute, 2))
+			_ = s.Start("hourly")
+
+			// Sleep through three runs
+			fc.Advance(3*time.Hour + 30*time.Minute)
+			select {
+			case e := <-events:
+				if !e.Scheduled.Equal(testTime.Add(1*time.Hour)) || e.Missed != 3 || e.Runs != tc.runs {
+					t.Errorf("Misfired Event %+v", e)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("Timed out waiting for the Misfired Event")
+			}
+			for i := 0; i < tc.runs; i++ {
+				waitForRun(t, runs, "hourly")
+			}
+			expectNoRun(t, runs)
+			if next, _ := s.Forecast("hourly", 1); len(next) != 1 || !next[0].Equal(testTime.Add(4*time.Hour)) {
+				t.Errorf("Next run after the misfire is %v", next)
+			}
+		})
+	}
+}
+
+func TestSchedulerMisfireRunAllRescheduleOnce(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	defer s.Shutdown(context.Background())
+	events := s.Subscribe(EventTypes(EventType_Misfired))
+	runs := make(chan string, 10)
+	// A Repeating Timer moves on to its next run every time it is asked for one
+	timer, _ := NewRepeating(testTime.Add(1*time.Hour), 1*time.Hour, -1)
+	_ = s.Add(context.Background(), "hourly", timer, func(ctx context.Context) { runs <- "hourly" }, WithMisfirePolicy(MisfirePolicy_RunAll, 5*time.Minute, 3))
+	_ = s.Start("hourly")
+
+	fc.Advance(3*time.Hour + 30*time.Minute)
+	select {
+	case e := <-events:
+		if e.Missed != 3 || e.Runs != 3 {
+			t.Errorf("Misfired Event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the Misfired Event")
+	}
+	for i := 0; i < 3; i++ {
+		waitForRun(t, runs, "hourly")
+	}
+	expectNoRun(t, runs)
+	if next, _ := s.Forecast("hourly", 1); len(next) != 1 || !next[0].Equal(testTime.Add(4*time.Hour)) {
+		t.Errorf("Next run after the catch up runs is %v", next)
+	}
+	fc.Advance(30 * time.Minute)
+	waitForRun(t, runs, "hourly")
+}
+
+func TestSchedulerRepeatingRuns(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	s := NewScheduler(WithLogger(logr.Discard()), WithClock(fc))
+	defer s.Shutdown(context.Background())
+	runs := make(chan string, 10)
+	timer, _ := ParseTimer("R5/2021-11-01T01:00:00Z/PT1H")
+	_ = s.Add(context.Background(), "hourly", timer, func(ctx context.Context) { runs <- "hourly" })
+	_ = s.Start("hourly")
+
+	// Every one of the 5 runs happens, an hour apart
+	for i := 1; i <= 5; i++ {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			info, _ := s.Describe("hourly")
+			if info.NextRun.Equal(testTime.Add(time.Duration(i)*time.Hour)) && fc.Waiters() > 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Run %d is not scheduled, next run is %s", i, info.NextRun)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		fc.Advance(1 * time.Hour)
+		waitForRun(t, runs, "hourly")
+	}
+	fc.Advance(5 * time.Hour)
+	expectNoRun(t, runs)
+}
@@ -0,0 +1,230 @@
+package taskmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+//Store persists TaskRecords, so a Scheduler created with WithStore can pick up where it left off after a restart.
+//Implementations must be safe for concurrent use. See the store package for a in-memory and a file Store.
+type Store interface {
+	// Load Returns every saved TaskRecord
+	Load() ([]TaskRecord, error)
+	// Save Saves rec, replacing any TaskRecord with the same ID
+	Save(rec TaskRecord) error
+	// Delete Deletes the TaskRecord with the given id, if any
+	Delete(id string) error
+}
+
+//StatefulMiddleware is an optional Interface Execution or Retry Middleware can implement to have the state they
+//hold for a Task, such as retry attempt counts, saved in the Store of the Scheduler.
+type StatefulMiddleware interface {
+	// SaveState Returns the state held for s
+	SaveState(s *Task) ([]byte, error)
+	// RestoreState Restores the state held for s from state returned by SaveState. It is called after Initilize.
+	RestoreState(s *Task, state []byte) error
+}
+
+//TaskRecord The definition and run state of a Task saved in a Store
+type TaskRecord struct {
+	ID string
+	// Spec of the Timer, if it implements TimerSpec
+	Timer string
+	// Job type and params, if the Task was added with Scheduler.AddJob
+	JobType  string
+	Params   json.RawMessage
+	Labels   map[string]string
+	Priority int
+	// Options of the Task, re-applied when a Task added with Scheduler.AddJob is restored. Middleware can not be
+	// saved, see WithRestoreOptions. HistorySize is nil in records saved by older versions.
+	RunTimeout       time.Duration
+	HardKill         time.Duration
+	HistorySize      *int
+	Jitter           time.Duration
+	MisfirePolicy    MisfirePolicy
+	MisfireThreshold time.Duration
+	MisfireLimit     int
+	Started          bool
+	Paused           bool
+	NextRun          time.Time
+	// Outcome of the last run, and counts of runs, as reported by TaskInfo
+	LastStart   time.Time
+	LastFinish  time.Time
+	LastError   string
+	LastOutcome RunOutcome
+	Runs        uint64
+	Failures    uint64
+	Retries     uint64
+	Defers      uint64
+	// State of the StatefulMiddleware of the Task, by middlewareKey
+	MiddlewareState map[string][]byte
+	// The last runs of the Task, newest first
+	History []RunRecord
+}
+
+// middlewareKey identifies the Middleware at position i of the Execution ("exec") or Retry ("retry") Middleware
+func middlewareKey(kind string, i int, mw interface{}) string {
+	return fmt.Sprintf("%s/%d/%T", kind, i, mw)
+}
+
+// timerSpec returns the Spec of timer, or "" if it does not implement TimerSpec
+func timerSpec(timer Timer) string {
+	if spec, ok := timer.(TimerSpec); ok {
+		return spec.Spec()
+	}
+	return ""
+}
+
+// record returns the TaskRecord of the Task
+func (s *Task) record() TaskRecord {
+	info := s.Describe()
+	s.mx.RLock()
+	runTimeout, hardKill, jitter := s.runTimeout, s.hardKill, s.jitter
+	misfirePolicy, misfireThreshold, misfireLimit := s.misfirePolicy, s.misfireThreshold, s.misfireLimit
+	s.mx.RUnlock()
+	historySize := s.history.capacity()
+	rec := TaskRecord{
+		ID:               s.id,
+		Timer:            info.Timer,
+		JobType:          info.JobType,
+		Params:           info.Params,
+		Labels:           info.Labels,
+		Priority:         info.Priority,
+		RunTimeout:       runTimeout,
+		HardKill:         hardKill,
+		HistorySize:      &historySize,
+		Jitter:           jitter,
+		MisfirePolicy:    misfirePolicy,
+		MisfireThreshold: misfireThreshold,
+		MisfireLimit:     misfireLimit,
+		Started:          s.isStarted(),
+		Paused:           s.IsPaused(),
+		NextRun:          info.NextRun,
+		LastStart:        info.LastStart,
+		LastFinish:       info.LastFinish,
+		LastError:        info.LastError,
+		LastOutcome:      info.LastOutcome,
+		Runs:             info.Runs,
+		Failures:         info.Failures,
+		Retries:          info.Retries,
+		Defers:           info.Defers,
+		History:          s.History(),
+	}
+	rec.MiddlewareState = make(map[string][]byte)
+	saveState := func(key string, mw interface{}) {
+		smw, ok := mw.(StatefulMiddleware)
+		if !ok {
+			return
+		}
+		state, err := smw.SaveState(s)
+		if err != nil {
+			s.Logger.Error(err, "Saving Middleware State Failed", "middleware", mw)
+			return
+		}
+		rec.MiddlewareState[key] = state
+	}
+	executationMiddleWares, retryMiddlewares := s.middlewares()
+	for i, mw := range executationMiddleWares {
+		saveState(middlewareKey("exec", i, mw), mw)
+	}
+	for i, mw := range retryMiddlewares {
+		saveState(middlewareKey("retry", i, mw), mw)
+	}
+	return rec
+}
+
+// options returns the Options of the Task saved in rec
+func (rec TaskRecord) options() []Option {
+	opts := []Option{
+		WithLabels(rec.Labels),
+		WithPriority(rec.Priority),
+		WithRunTimeout(rec.RunTimeout),
+		WithRunHardKill(rec.HardKill),
+		WithJitter(rec.Jitter),
+		WithMisfirePolicy(rec.MisfirePolicy, rec.MisfireThreshold, rec.MisfireLimit),
+	}
+	// Records saved by older versions have no history size, so keep the default
+	if rec.HistorySize != nil {
+		opts = append(opts, WithHistorySize(*rec.HistorySize))
+	}
+	return opts
+}
+
+// persist saves the TaskRecord of the Task, if it has been added to a Scheduler with a Store
+func (s *Task) persist() {
+	if s.store == nil {
+		return
+	}
+	// Serialize Saves, so a older TaskRecord never replaces a newer one
+	s.persistMx.Lock()
+	defer s.persistMx.Unlock()
+	if err := s.store.Save(s.record()); err != nil {
+		s.Logger.Error(err, "Saving Task to Store Failed")
+	}
+}
+
+// restore restores the run state of the Task from rec. The Middleware state is restored when the Task is Started.
+func (s *Task) restore(rec TaskRecord) {
+	if !rec.NextRun.IsZero() {
+		s.nextRun.Set(rec.NextRun)
+	} else if !rec.LastStart.IsZero() {
+		// Without a saved next run, runs missed since the last run are only found if there is a misfire threshold
+		s.mx.RLock()
+		threshold := s.misfireThreshold
+		s.mx.RUnlock()
+		if threshold > 0 {
+			if next, done := s.nextAfter(rec.LastStart); !done && next.Before(s.GetNextRun()) {
+				s.nextRun.Set(next)
+			}
+		}
+	}
+	s.setPaused(rec.Paused)
+
+	s.stats.mx.Lock()
+	s.stats.lastStart = rec.LastStart
+	s.stats.lastFinish = rec.LastFinish
+	s.stats.lastError = rec.LastError
+	s.stats.lastOutcome = rec.LastOutcome
+	s.stats.runs = rec.Runs
+	s.stats.failures = rec.Failures
+	s.stats.retries = rec.Retries
+	s.stats.defers = rec.Defers
+	s.stats.mx.Unlock()
+
+	for i := len(rec.History) - 1; i >= 0; i-- {
+		s.history.add(&rec.History[i])
+	}
+
+	s.mx.Lock()
+	s.middlewareState = rec.MiddlewareState
+	s.mx.Unlock()
+}
+
+// restoreMiddlewareState restores the state of the StatefulMiddleware of the Task, once, after they are Initilized.
+// Must be called with s.mx held.
+func (s *Task) restoreMiddlewareState() {
+	if s.middlewareState == nil {
+		return
+	}
+	restoreState := func(key string, mw interface{}) {
+		smw, ok := mw.(StatefulMiddleware)
+		if !ok {
+			return
+		}
+		state, ok := s.middlewareState[key]
+		if !ok {
+			return
+		}
+		if err := smw.RestoreState(s, state); err != nil {
+			s.Logger.Error(err, "Restoring Middleware State Failed", "middleware", mw)
+		}
+	}
+	for i, mw := range s.executationMiddleWares {
+		restoreState(middlewareKey("exec", i, mw), mw)
+	}
+	for i, mw := range s.retryMiddlewares {
+		restoreState(middlewareKey("retry", i, mw), mw)
+	}
+	s.middlewareState = nil
+}
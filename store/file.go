@@ -0,0 +1,131 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Fishwaldo/go-taskmanager"
+)
+
+var _ taskmanager.Store = (*File)(nil)
+
+//File A Store that keeps TaskRecords in a single JSON file. Every Save or Delete rewrites the file to a temporary
+//file in the same directory, syncs it and renames it over the old one, so a crash leaves either the old or the new
+//file, never a partial one.
+type File struct {
+	mx      sync.Mutex
+	path    string
+	records map[string]taskmanager.TaskRecord
+}
+
+type fileContents struct {
+	Version int
+	Tasks   []taskmanager.TaskRecord
+}
+
+const fileVersion = 1
+
+//NewFile Returns a File Store saving to path, loading the TaskRecords already saved there. path is created on the
+//first Save if it does not exist.
+func NewFile(path string) (*File, error) {
+	f := &File{
+		path:    path,
+		records: make(map[string]taskmanager.TaskRecord),
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var contents fileContents
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return nil, fmt.Errorf("store file %s is corrupt: %w", path, err)
+	}
+	if contents.Version != fileVersion {
+		return nil, fmt.Errorf("store file %s has unsupported version %d", path, contents.Version)
+	}
+	for _, rec := range contents.Tasks {
+		f.records[rec.ID] = rec
+	}
+	return f, nil
+}
+
+//Load Returns every saved TaskRecord, sorted by ID
+func (f *File) Load() ([]taskmanager.TaskRecord, error) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return sortedRecords(f.records), nil
+}
+
+//Save Saves rec, replacing any TaskRecord with the same ID
+func (f *File) Save(rec taskmanager.TaskRecord) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	old, existed := f.records[rec.ID]
+	f.records[rec.ID] = rec
+	if err := f.write(); err != nil {
+		if existed {
+			f.records[rec.ID] = old
+		} else {
+			delete(f.records, rec.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+//Delete Deletes the TaskRecord with the given id, if any
+func (f *File) Delete(id string) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	old, existed := f.records[id]
+	if !existed {
+		return nil
+	}
+	delete(f.records, id)
+	if err := f.write(); err != nil {
+		f.records[id] = old
+		return err
+	}
+	return nil
+}
+
+// write atomically replaces the file with the current records, must be called with f.mx held
+func (f *File) write() error {
+	data, err := json.MarshalIndent(fileContents{Version: fileVersion, Tasks: sortedRecords(f.records)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(f.path)
+	tmp, err := ioutil.TempFile(dir, filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+	// Sync the directory so the rename itself survives a crash
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager"
+	"github.com/Fishwaldo/go-taskmanager/clock"
+	"github.com/Fishwaldo/go-taskmanager/job"
+	"github.com/go-logr/logr"
+)
+
+var testTime = time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile Returned Error: %s", err.Error())
+	}
+	rec := taskmanager.TaskRecord{
+		ID:      "export",
+		Timer:   "@every 1h0m0s",
+		NextRun: testTime,
+		History: []taskmanager.RunRecord{{InstanceID: "1", State: job.FAILED, Error: "test error"}},
+	}
+	if err := f.Save(rec); err != nil {
+		t.Fatalf("Save Returned Error: %s", err.Error())
+	}
+	if err := f.Save(taskmanager.TaskRecord{ID: "backup"}); err != nil {
+		t.Fatalf("Save Returned Error: %s", err.Error())
+	}
+	if err := f.Delete("backup"); err != nil {
+		t.Fatalf("Delete Returned Error: %s", err.Error())
+	}
+
+	f, err = NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile Returned Error: %s", err.Error())
+	}
+	recs, _ := f.Load()
+	if len(recs) != 1 || recs[0].ID != "export" || !recs[0].NextRun.Equal(testTime) {
+		t.Fatalf("Loaded %+v", recs)
+	}
+	if len(recs[0].History) != 1 || recs[0].History[0].State != job.FAILED {
+		t.Errorf("Loaded History %+v", recs[0].History)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFile(path); err == nil {
+		t.Errorf("NewFile of a corrupt file did not return an error")
+	}
+}
+
+func TestSchedulerRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	f, _ := NewFile(path)
+	fc := clock.NewFake(testTime)
+	s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(fc), taskmanager.WithStore(f))
+	runs := make(chan struct{}, 10)
+	add := func(s *taskmanager.Scheduler) {
+		timer, _ := taskmanager.NewFixed(1 * time.Hour)
+		if err := s.Add(context.Background(), "export", timer, func(ctx context.Context) { runs <- struct{}{} }); err != nil {
+			t.Fatalf("Add Returned Error: %s", err.Error())
+		}
+	}
+	add(s)
+	_ = s.Start("export")
+	fc.Advance(30 * time.Minute)
+	if _, err := s.RunNow("export", taskmanager.WithRunBypassMiddleware()); err != nil {
+		t.Fatalf("RunNow Returned Error: %s", err.Error())
+	}
+	<-runs
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if history, _ := s.History("export"); len(history) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for the run to be recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	before, _ := s.Describe("export")
+	_ = s.Shutdown(context.Background())
+
+	// Restart, and add the same Task again
+	f, err := NewFile(path)
+	if err != nil {
+		t.Fatalf("NewFile Returned Error: %s", err.Error())
+	}
+	s = taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(fc), taskmanager.WithStore(f))
+	defer s.Shutdown(context.Background())
+	add(s)
+	after, _ := s.Describe("export")
+	if after.State != taskmanager.TaskState_Scheduled {
+		t.Errorf("Restored Task State is %s, not SCHEDULED", after.State)
+	}
+	if !after.NextRun.Equal(before.NextRun) || !after.NextRun.Equal(testTime.Add(1*time.Hour)) {
+		t.Errorf("Restored NextRun is %s, not %s", after.NextRun, before.NextRun)
+	}
+	if after.Runs != 1 || after.LastOutcome != taskmanager.RunOutcome_Succeeded || !after.LastStart.Equal(before.LastStart) {
+		t.Errorf("Restored Task Described as %+v", after)
+	}
+	if history, _ := s.History("export"); len(history) != 1 {
+		t.Errorf("Restored History is %+v", history)
+	}
+
+	// The saved next run is still due after the restart
+	fc.Advance(30 * time.Minute)
+	select {
+	case <-runs:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the restored run")
+	}
+}
+
+// testMiddleware is a Execution Middleware that lets every run through
+type testMiddleware struct{}
+
+func (mw *testMiddleware) PreHandler(s *taskmanager.Task) (taskmanager.MWResult, error) {
+	return taskmanager.MWResult{Result: taskmanager.MWResult_NextMW}, nil
+}
+
+func (mw *testMiddleware) PostHandler(s *taskmanager.Task, err error) taskmanager.MWResult {
+	return taskmanager.MWResult{Result: taskmanager.MWResult_NextMW}
+}
+
+func (mw *testMiddleware) Reset(s *taskmanager.Task) {}
+
+func (mw *testMiddleware) Initilize(s *taskmanager.Task) {}
+
+func TestSchedulerRestoreJobs(t *testing.T) {
+	st := NewMemory()
+	registry := taskmanager.NewJobRegistry()
+	runs := make(chan string, 10)
+	_ = registry.Register("echo", func(params json.RawMessage) (func(context.Context) error, error) {
+		var p struct{ Msg string }
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context) error {
+			runs <- p.Msg
+			return nil
+		}, nil
+	})
+	fc := clock.NewFake(testTime)
+	var restored []string
+	restoreOptions := taskmanager.WithRestoreOptions(func(rec taskmanager.TaskRecord) []taskmanager.Option {
+		restored = append(restored, rec.ID)
+		return []taskmanager.Option{taskmanager.WithExecutationMiddleWare(&testMiddleware{})}
+	})
+	newScheduler := func() *taskmanager.Scheduler {
+		return taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(fc), taskmanager.WithStore(st), taskmanager.WithJobRegistry(registry), restoreOptions)
+	}
+	s := newScheduler()
+	opts := []taskmanager.Option{
+		taskmanager.WithLabels(map[string]string{"team": "ops"}),
+		taskmanager.WithExecutationMiddleWare(&testMiddleware{}),
+		taskmanager.WithRunTimeout(10 * time.Minute),
+		taskmanager.WithRunHardKill(time.Minute),
+		taskmanager.WithHistorySize(3),
+		taskmanager.WithMisfirePolicy(taskmanager.MisfirePolicy_RunAll, 5*time.Minute, 2),
+	}
+	if err := s.AddJob(context.Background(), "hello", "@every 1h", "echo", json.RawMessage(`{"Msg":"hello"}`), opts...); err != nil {
+		t.Fatalf("AddJob Returned Error: %s", err.Error())
+	}
+	_ = s.Start("hello")
+	// A History size of 0 is restored, rather than the default
+	if err := s.AddJob(context.Background(), "quiet", "@every 1h", "echo", json.RawMessage(`{"Msg":"quiet"}`), taskmanager.WithHistorySize(0)); err != nil {
+		t.Fatalf("AddJob Returned Error: %s", err.Error())
+	}
+	_ = s.Shutdown(context.Background())
+
+	// The Task is rebuilt without being added again
+	s = newScheduler()
+	defer s.Shutdown(context.Background())
+	info, err := s.Describe("hello")
+	if err != nil {
+		t.Fatalf("Task was not restored: %s", err.Error())
+	}
+	if info.JobType != "echo" || info.State != taskmanager.TaskState_Scheduled || info.Labels["team"] != "ops" || len(info.Middlewares) != 1 {
+		t.Errorf("Restored Task Described as %+v", info)
+	}
+	if len(restored) != 2 || restored[0] != "hello" || restored[1] != "quiet" {
+		t.Errorf("WithRestoreOptions called for %v", restored)
+	}
+	recs, _ := st.Load()
+	saved := make(map[string]taskmanager.TaskRecord)
+	for _, rec := range recs {
+		saved[rec.ID] = rec
+	}
+	if rec := saved["hello"]; len(recs) != 2 || rec.RunTimeout != 10*time.Minute || rec.HardKill != time.Minute || rec.HistorySize == nil || *rec.HistorySize != 3 ||
+		rec.MisfirePolicy != taskmanager.MisfirePolicy_RunAll || rec.MisfireThreshold != 5*time.Minute || rec.MisfireLimit != 2 {
+		t.Errorf("Restored Task saved as %+v", recs)
+	}
+	if rec := saved["quiet"]; rec.HistorySize == nil || *rec.HistorySize != 0 {
+		t.Errorf("Restored Task with no History saved as %+v", rec)
+	}
+	fc.Advance(1 * time.Hour)
+	select {
+	case msg := <-runs:
+		if msg != "hello" {
+			t.Errorf("Restored Job ran with %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the restored Job to run")
+	}
+}
+
+func TestSchedulerRestoreMisfire(t *testing.T) {
+	st := NewMemory()
+	fc := clock.NewFake(testTime)
+	var events <-chan taskmanager.Event
+	add := func() *taskmanager.Scheduler {
+		s := taskmanager.NewScheduler(taskmanager.WithLogger(logr.Discard()), taskmanager.WithClock(fc), taskmanager.WithStore(st))
+		events = s.Subscribe(taskmanager.EventTypes(taskmanager.EventType_Misfired))
+		timer, _ := taskmanager.NewCron("0 * * * *")
+		opt := taskmanager.WithMisfirePolicy(taskmanager.MisfirePolicy_Skip, 5*time.Minute, 0)
+		if err := s.Add(context.Background(), "hourly", timer, func(ctx context.Context) {}, opt); err != nil {
+			t.Fatalf("Add Returned Error: %s", err.Error())
+		}
+		return s
+	}
+	s := add()
+	_ = s.Start("hourly")
+	_ = s.Shutdown(context.Background())
+
+	// The process is down through two runs
+	fc.Advance(2*time.Hour + 30*time.Minute)
+	// Added again, the Task is Started as it was before
+	s = add()
+	defer s.Shutdown(context.Background())
+	select {
+	case e := <-events:
+		if !e.Scheduled.Equal(testTime.Add(1*time.Hour)) || e.Missed != 2 || e.Runs != 0 {
+			t.Errorf("Misfired Event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the Misfired Event")
+	}
+}
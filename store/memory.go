@@ -0,0 +1,56 @@
+package store
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/Fishwaldo/go-taskmanager"
+)
+
+var _ taskmanager.Store = (*Memory)(nil)
+
+//Memory A Store that keeps TaskRecords in memory, so they are lost when the process exits. Useful for tests, and
+//to carry Tasks over between Schedulers in the same process.
+type Memory struct {
+	mx      sync.RWMutex
+	records map[string]taskmanager.TaskRecord
+}
+
+//NewMemory Returns a empty Memory Store
+func NewMemory() *Memory {
+	return &Memory{
+		records: make(map[string]taskmanager.TaskRecord),
+	}
+}
+
+//Load Returns every saved TaskRecord, sorted by ID
+func (m *Memory) Load() ([]taskmanager.TaskRecord, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return sortedRecords(m.records), nil
+}
+
+//Save Saves rec, replacing any TaskRecord with the same ID
+func (m *Memory) Save(rec taskmanager.TaskRecord) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.records[rec.ID] = rec
+	return nil
+}
+
+//Delete Deletes the TaskRecord with the given id, if any
+func (m *Memory) Delete(id string) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	delete(m.records, id)
+	return nil
+}
+
+func sortedRecords(records map[string]taskmanager.TaskRecord) []taskmanager.TaskRecord {
+	recs := make([]taskmanager.TaskRecord, 0, len(records))
+	for _, rec := range records {
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].ID < recs[j].ID })
+	return recs
+}
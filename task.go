@@ -3,14 +3,18 @@ package taskmanager
 import (
 	//	"errors"
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/armon/go-metrics"
+	"github.com/google/uuid"
 	"github.com/sasha-s/go-deadlock"
 	"github.com/go-logr/logr"
+	"github.com/Fishwaldo/go-taskmanager/clock"
 	"github.com/Fishwaldo/go-taskmanager/job"
 	"github.com/Fishwaldo/go-taskmanager/joberrors"
 	schedmetrics "github.com/Fishwaldo/go-taskmanager/metrics"
@@ -31,6 +35,12 @@ type RetryMiddleware interface {
 	Initilize(s *Task)
 }
 
+// TeardownMiddleWare is an optional Interface Execution or Retry Middleware can implement
+// to release any state they hold for a Task when the Task is removed from the Scheduler.
+type TeardownMiddleWare interface {
+	Teardown(s *Task)
+}
+
 type RetryResult_Op int
 
 const (
@@ -39,6 +49,19 @@ const (
 	RetryResult_NextMW
 )
 
+func (r RetryResult_Op) String() string {
+	switch r {
+	case RetryResult_Retry:
+		return "RETRY"
+	case RetryResult_NoRetry:
+		return "NORETRY"
+	case RetryResult_NextMW:
+		return "NEXTMW"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 type RetryResult struct {
 	Result RetryResult_Op
 	Delay  time.Duration
@@ -52,6 +75,19 @@ const (
 	MWResult_NextMW
 )
 
+func (r MWResult_Op) String() string {
+	switch r {
+	case MWResult_Cancel:
+		return "CANCEL"
+	case MWResult_Defer:
+		return "DEFER"
+	case MWResult_NextMW:
+		return "NEXTMW"
+	default:
+		return "UNKNOWN"
+	}
+}
+
 type MWResult struct {
 	Result MWResult_Op
 }
@@ -81,17 +117,23 @@ type Task struct {
 	id string
 
 	// Source function used to create job.Job
-	jobSrcFunc func(ctx context.Context)
+	jobSrcFunc func(ctx context.Context) error
 
 	// Timer used to trigger Jobs
 	timer Timer
 
+	// Serializes the use of the Timer, as runs of the Task can finish concurrently
+	timerMx deadlock.Mutex
+
 	// Next Scheduled Run
 	nextRun nextRuni
 
 	// Signal Channel to Update Scheduler Class about changes
 	updateSignal chan updateSignalOp
 
+	// Closed when the Scheduler loop exits, and no longer reads updateSignal
+	schedulerDone <-chan struct{}
+
 	// SignalChan for termination
 	stopScheduleSignal chan interface{}
 
@@ -115,10 +157,72 @@ type Task struct {
 
 	// Context for Jobs
 	Ctx context.Context
+
+	// Cancel the Context for Jobs
+	cancel context.CancelFunc
+
+	// Clock used for Timers and Jobs
+	clock clock.Clock
+
+	// Priority of the Task when several runs are waiting to be dispatched, higher runs first
+	priority int
+
+	// Labels used to select Tasks
+	labels map[string]string
+
+	// Paused Tasks are not dispatched
+	paused bool
+
+	// Maximum time a Job can run for before its Context is canceled
+	runTimeout time.Duration
+
+	// How long to wait for a Job to return after its timeout before abandoning it
+	hardKill time.Duration
+
+	// Outcome of the runs of the Task, reported by Describe
+	stats taskStats
+
+	// The last runs of the Task, reported by History
+	history *runHistory
+
+	// Lifecycle Events are published on events, if the Task has been added to a Scheduler
+	events *eventBus
+
+	// The Task is saved in store, if it has been added to a Scheduler with a Store
+	store     Store
+	persistMx sync.Mutex
+
+	// Middleware state restored from store, applied when the Task is next Started
+	middlewareState map[string][]byte
+
+	// Job type and params the Task was created from by Scheduler.AddJob, if any
+	jobType string
+	params  json.RawMessage
+
+	// What to do about missed runs, see WithMisfirePolicy
+	misfirePolicy    MisfirePolicy
+	misfireThreshold time.Duration
+	misfireLimit     int
+
+	// Maximum offset runs of the Task are delayed by, see WithJitter
+	jitter time.Duration
+
+	// Runs started with Scheduler.RunNow that have not started yet, and whether they were canceled
+	pendingRuns map[string]bool
 }
 
 // NewSchedule Create a new schedule for` jobFunc func()` that will run according to `timer Timer` with the supplied []Options
 func NewSchedule(ctx context.Context, id string, timer Timer, jobFunc func(context.Context), opts ...Option) *Task {
+	return NewScheduleWithError(ctx, id, timer, func(ctx context.Context) error {
+		jobFunc(ctx)
+		return nil
+	}, opts...)
+}
+
+// NewScheduleWithError Create a new schedule for` jobFunc func() error` that will run according to `timer Timer` with
+// the supplied []Options. A error returned by jobFunc is passed to the Post Execution and Retry Middleware as a
+// joberrors.FailedJobError with a ErrorType of joberrors.Error_JobError
+func NewScheduleWithError(ctx context.Context, id string, timer Timer, jobFunc func(context.Context) error, opts ...Option) *Task {
 	var options = defaultTaskOptions()
 
 	// Apply Options
@@ -126,6 +230,7 @@ func NewSchedule(ctx context.Context, id string, timer Timer, jobFunc func(conte
 		option.apply(options)
 	}
 
+	ctx, cancel := context.WithCancel(ctx)
 	s := &Task{
 		id:                     id,
 		jobSrcFunc:             jobFunc,
@@ -135,6 +240,25 @@ func NewSchedule(ctx context.Context, id string, timer Timer, jobFunc func(conte
 		executationMiddleWares: options.executationmiddlewares,
 		retryMiddlewares:       options.retryMiddlewares,
 		Ctx:                    ctx,
+		cancel:                 cancel,
+		clock:                  options.clock,
+		priority:               options.priority,
+		labels:                 options.labels,
+		runTimeout:             options.runTimeout,
+		hardKill:               options.hardKill,
+		history:                newRunHistory(options.historySize),
+		jobType:                options.jobType,
+		params:                 options.params,
+		misfirePolicy:          options.misfirePolicy,
+		misfireThreshold:       options.misfireThreshold,
+		misfireLimit:           options.misfireLimit,
+		jitter:                 options.jitter,
+		pendingRuns:            make(map[string]bool),
+	}
+	timer = bindTimer(timer, id, options.jitter)
+	s.timer = timer
+	if cs, ok := timer.(ClockSetter); ok {
+		cs.SetClock(options.clock)
 	}
 	t, _ := timer.Next()
 	s.nextRun.Set(t)
@@ -171,6 +295,7 @@ func (s *Task) Start() {
 		s.Logger.V(1).Info("Initilized Retry Middleware", "middleware", mw)
 		mw.Initilize(s)
 	}
+	s.restoreMiddlewareState()
 
 	//go s.scheduleLoop()
 	//go func() {}()
@@ -185,29 +310,67 @@ func (s *Task) Start() {
 //		3. STOPPED: No Effect
 //		4. FINISHED: No Effect
 func (s *Task) Stop() {
-	s.mx.Lock()
-	defer s.mx.Unlock()
-
-	// Stop control loop
-	s.Logger.Info("Stopping Schedule...")
-	s.stopScheduleSignal <- struct{}{}
+	if !s.stopSchedule() {
+		return
+	}
 
 	// Print No. of Active Jobs
-	if noOfActiveJobs := s.activeJobs.len(); s.activeJobs.len() > 0 {
+	if noOfActiveJobs := s.activeJobs.len(); noOfActiveJobs > 0 {
 		s.Logger.Info("Waiting for active jobs still running...", "jobs", noOfActiveJobs)
 	}
 
 	s.wg.Wait()
 	s.Logger.Info("Job Schedule Stopped")
 	metrics.SetGaugeWithLabels(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_Up), 0, []metrics.Label{{Name: "id", Value: s.id}})
+}
+
+// stopSchedule signals the Schedule to stop without waiting for running jobs. Returns false if it was not running
+func (s *Task) stopSchedule() bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.stopScheduleSignal == nil {
+		s.Logger.V(1).Info("Job Schedule Not Running")
+		return false
+	}
+
+	// Stop control loop
+	s.Logger.Info("Stopping Schedule...")
 	close(s.stopScheduleSignal)
+	s.stopScheduleSignal = nil
+	return true
+}
+
+// remove cancels the Context of any running Jobs, waits for them to finish and then tears down the Middleware.
+// A removed Task can not be started again.
+func (s *Task) remove() {
+	s.Logger.Info("Removing Schedule...")
+	s.cancel()
+	s.Stop()
+	s.wg.Wait()
+
+	for _, mw := range s.executationMiddleWares {
+		if td, ok := mw.(TeardownMiddleWare); ok {
+			s.Logger.V(1).Info("Teardown Executation Middleware", "middleware", mw)
+			td.Teardown(s)
+		}
+	}
+	for _, mw := range s.retryMiddlewares {
+		if td, ok := mw.(TeardownMiddleWare); ok {
+			s.Logger.V(1).Info("Teardown Retry Middleware", "middleware", mw)
+			td.Teardown(s)
+		}
+	}
+	metrics.SetGaugeWithLabels(schedmetrics.GetMetricsGaugeKey(schedmetrics.Metrics_Guage_Up), 0, []metrics.Label{{Name: "id", Value: s.id}})
+	s.Logger.Info("Job Schedule Removed")
 }
 
-func (s *Task) runPreExecutationMiddlware() (MWResult, error) {
-	for _, middleware := range s.executationMiddleWares {
+func (s *Task) runPreExecutationMiddlware(rec *RunRecord) (MWResult, error) {
+	executationMiddleWares, _ := s.middlewares()
+	for _, middleware := range executationMiddleWares {
 		s.Logger.V(1).Info("Running Handler", "middleware", middleware)
 		metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_PreExecutationRuns), 1, []metrics.Label{{Name: "id", Value: s.id}, {Name: "middleware", Value: fmt.Sprintf("%T", middleware)}})
 		result, err := middleware.PreHandler(s)
+		rec.decide(MiddlewareStage_PreExecution, middleware, result.Result.String(), 0, err)
 		if err != nil {
 			s.Logger.Error(err, "Middleware Returned Error", "middleware", middleware, "result", result)
 		} else {
@@ -221,39 +384,56 @@ func (s *Task) runPreExecutationMiddlware() (MWResult, error) {
 		case MWResult_NextMW:
 			continue
 		case MWResult_Cancel:
+			e := Event{Type: EventType_RunCanceled, InstanceID: rec.InstanceID, Middleware: fmt.Sprintf("%T", middleware)}
+			if err != nil {
+				e.Error = err.Error()
+			}
+			s.emit(e)
 			return result, err
 		}
 	}
 	return MWResult{Result: MWResult_NextMW}, nil
 }
 
-func (s *Task) runRetryMiddleware(prerun bool, err error) {
-	for _, retrymiddleware := range s.retryMiddlewares {
+// runRetryMiddleware runs the Retry Middleware for the run recorded by rec, returning true if any of it rescheduled the
+// Timer to retry the run
+func (s *Task) runRetryMiddleware(rec *RunRecord, prerun bool, err error) bool {
+	retried := false
+	_, retryMiddlewares := s.middlewares()
+	for _, retrymiddleware := range retryMiddlewares {
 		s.Logger.V(1).Info("Running Retry Middleware", "middleware", retrymiddleware)
 		metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_PreRetryRuns), 1, []metrics.Label{{Name: "id", Value: s.id}, {Name: "middleware", Value: fmt.Sprintf("%T", retrymiddleware)}, {Name: "Prerun", Value: strconv.FormatBool(prerun)}})
 
-		retryops, _ := retrymiddleware.Handler(s, prerun, err)
+		retryops, rerr := retrymiddleware.Handler(s, prerun, err)
+		rec.decide(MiddlewareStage_Retry, retrymiddleware, retryops.Result.String(), retryops.Delay, rerr)
 
 		switch retryops.Result {
 		case RetryResult_Retry:
 			s.Logger.V(1).Info("Retry Middleware Delayed Job", "middleware", retrymiddleware, "duration", retryops.Delay)
 			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_PreRetryRetries), 1, []metrics.Label{{Name: "id", Value: s.id}, {Name: "middleware", Value: fmt.Sprintf("%T", retrymiddleware)}, {Name: "Prerun", Value: strconv.FormatBool(prerun)}})
+			s.stats.recordRetry()
 			s.retryJob(retryops.Delay)
+			retried = true
+			s.emit(Event{Type: EventType_RetryScheduled, InstanceID: rec.InstanceID, Middleware: fmt.Sprintf("%T", retrymiddleware), Delay: retryops.Delay})
 		case RetryResult_NoRetry:
 			s.Logger.V(1).Info("Retry Middleware Canceled Retries", "middleware", retrymiddleware)
+			s.emit(Event{Type: EventType_RetriesExhausted, InstanceID: rec.InstanceID, Middleware: fmt.Sprintf("%T", retrymiddleware)})
 			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_PreRetryResets), 1, []metrics.Label{{Name: "id", Value: s.id}, {Name: "middleware", Value: fmt.Sprintf("%T", retrymiddleware)}, {Name: "Prerun", Value: strconv.FormatBool(prerun)}})
 		case RetryResult_NextMW:
 			s.Logger.V(1).Info("Retry Middleware Skipped", "middleware", retrymiddleware)
 			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_PreRetrySkips), 1, []metrics.Label{{Name: "id", Value: s.id}, {Name: "middleware", Value: fmt.Sprintf("%T", retrymiddleware)}, {Name: "Prerun", Value: strconv.FormatBool(prerun)}})
 		}
 	}
+	return retried
 }
 
-func (s *Task) runPostExecutionHandler(err error) MWResult {
-	for _, postmiddleware := range s.executationMiddleWares {
+func (s *Task) runPostExecutionHandler(rec *RunRecord, err error) MWResult {
+	executationMiddleWares, _ := s.middlewares()
+	for _, postmiddleware := range executationMiddleWares {
 		s.Logger.V(1).Info("Running PostHandler Middlware", "middleware", postmiddleware)
 		metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_PostExecutationFailedRuns), 1, []metrics.Label{{Name: "id", Value: s.id}, {Name: "middleware", Value: fmt.Sprintf("%T", postmiddleware)}})
 		result := postmiddleware.PostHandler(s, err)
+		rec.decide(MiddlewareStage_PostExecution, postmiddleware, result.Result.String(), 0, nil)
 		switch result.Result {
 		case MWResult_Defer:
 			return MWResult{Result: MWResult_Defer}
@@ -267,12 +447,17 @@ func (s *Task) runPostExecutionHandler(err error) MWResult {
 	return MWResult{Result: MWResult_NextMW}
 }
 
-func (s *Task) runJobInstance(result chan interface{}) {
-	s.wg.Add(1)
-	defer s.wg.Done()
+func (s *Task) runJobInstance(rec *RunRecord, result chan interface{}) {
+	result <- s.execJobInstance(rec)
+}
 
+// execJobInstance synchronously runs a new instance of the Job with the instance id of rec, recording its outcome in rec
+func (s *Task) execJobInstance(rec *RunRecord) error {
 	// Create a new instance of s.jobSrcFunc
-	jobInstance := job.NewJob(s.Ctx, s.jobSrcFunc)
+	s.mx.RLock()
+	jobSrcFunc, runTimeout, hardKill := s.jobSrcFunc, s.runTimeout, s.hardKill
+	s.mx.RUnlock()
+	jobInstance := job.NewErrorJobWithID(s.Ctx, rec.InstanceID, jobSrcFunc, job.WithClock(s.clock), job.WithTimeout(runTimeout), job.WithHardKill(hardKill))
 
 	joblog := s.Logger.WithValues("instance", jobInstance.ID())
 	joblog.V(1).Info("Job Run Starting")
@@ -280,6 +465,9 @@ func (s *Task) runJobInstance(result chan interface{}) {
 	// Add to active jobs map
 	s.activeJobs.add(jobInstance)
 	defer s.activeJobs.delete(jobInstance)
+	if s.takePendingRun(rec.InstanceID) {
+		jobInstance.Cancel()
+	}
 
 	// Logs and Metrics --------------------------------------
 	// -------------------------------------------------------
@@ -291,7 +479,17 @@ func (s *Task) runJobInstance(result chan interface{}) {
 	// -------------------------------------------------------
 
 	// Synchronously Run Job Instance
+	rec.Start = s.clock.Now()
+	s.stats.recordStart(rec.Start)
+	s.emit(Event{Type: EventType_RunStarted, InstanceID: rec.InstanceID})
 	lastError := jobInstance.Run()
+	rec.Finish = s.clock.Now()
+	rec.State = jobInstance.State()
+	if lastError != nil {
+		rec.Error = lastError.Error()
+	}
+	s.stats.recordFinish(rec.Finish, rec.State, lastError)
+	s.emit(Event{Type: EventType_RunFinished, InstanceID: rec.InstanceID, State: rec.State, Error: rec.Error})
 
 	// -------------------------------------------------------
 	// Logs and Metrics --------------------------------------
@@ -302,14 +500,23 @@ func (s *Task) runJobInstance(result chan interface{}) {
 			WithValues("error", lastError.Error()).
 			Error(lastError, "Job Error")
 		metrics.IncrCounterWithLabels([]string{"sched", "runerrors"}, 1, labels)
-		result <- lastError
-	} else {
-		joblog.
-			WithValues("duration", jobInstance.ActualElapsed().Round(1*time.Millisecond)).
-			WithValues("state", jobInstance.State().String()).
-			Info("Job Finished")
-		result <- nil
+		switch jobInstance.State() {
+		case job.TIMEDOUT:
+			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_TimedOutJobs), 1, labels)
+		case job.ABANDONED:
+			joblog.Error(lastError, "Job Ignored Cancellation and was Abandoned")
+			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_TimedOutJobs), 1, labels)
+			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_AbandonedJobs), 1, labels)
+		case job.CANCELLED:
+			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_CancelledJobs), 1, labels)
+		}
+		return lastError
 	}
+	joblog.
+		WithValues("duration", jobInstance.ActualElapsed().Round(1*time.Millisecond)).
+		WithValues("state", jobInstance.State().String()).
+		Info("Job Finished")
+	return nil
 }
 
 func negativeToZero(nextRunDuration time.Duration) time.Duration {
@@ -323,7 +530,247 @@ func (s *Task) retryJob(in time.Duration) {
 	s.Logger.
 		WithValues("duration", in).
 		V(1).Info("Rescheduling Job")
-	s.timer.Reschedule(in)
+	s.timerMx.Lock()
+	defer s.timerMx.Unlock()
+	s.getTimer().Reschedule(in)
+}
+
+// cancelRun cancels the running Job instance with the given id, or the run started with Scheduler.RunNow with the
+// given id if it has not started yet, returning false if there is neither
+func (s *Task) cancelRun(instanceID string) bool {
+	if jobInstance, ok := s.activeJobs.get(instanceID); ok {
+		return jobInstance.Cancel()
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	canceled, ok := s.pendingRuns[instanceID]
+	if !ok || canceled {
+		return false
+	}
+	s.pendingRuns[instanceID] = true
+	return true
+}
+
+// addPendingRun records the run started with Scheduler.RunNow with the given id, so it can be canceled before it starts
+func (s *Task) addPendingRun(instanceID string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.pendingRuns[instanceID] = false
+}
+
+// pendingRunCanceled returns true if the pending run with the given id was canceled
+func (s *Task) pendingRunCanceled(instanceID string) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.pendingRuns[instanceID]
+}
+
+// takePendingRun forgets the pending run with the given id, returning true if it was canceled
+func (s *Task) takePendingRun(instanceID string) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	canceled := s.pendingRuns[instanceID]
+	delete(s.pendingRuns, instanceID)
+	return canceled
+}
+
+// cancelAllRuns cancels every running Job instance, returning the sorted ids of those cancelled
+func (s *Task) cancelAllRuns() []string {
+	ids := make([]string, 0)
+	for _, jobInstance := range s.activeJobs.all() {
+		if jobInstance.Cancel() {
+			ids = append(ids, jobInstance.ID())
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GetLabels Returns a copy of the Labels of the Task
+func (s *Task) GetLabels() map[string]string {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	labels := make(map[string]string, len(s.labels))
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// IsPaused Returns true if dispatching of the Task has been Paused
+func (s *Task) IsPaused() bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.paused
+}
+
+// setPaused sets the paused state of the Task, returning false if it was already in that state
+func (s *Task) setPaused(paused bool) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	if s.paused == paused {
+		return false
+	}
+	s.paused = paused
+	return true
+}
+
+// isStarted returns true if the Task has been Started and not Stopped
+func (s *Task) isStarted() bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.stopScheduleSignal != nil
+}
+
+// GetPriority Returns the Priority of the Task
+func (s *Task) GetPriority() int {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.priority
+}
+
+// middlewares returns the Execution and Retry Middleware of the Task
+func (s *Task) middlewares() ([]ExecutionMiddleWare, []RetryMiddleware) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.executationMiddleWares, s.retryMiddlewares
+}
+
+// getTimer returns the Timer of the Task
+func (s *Task) getTimer() Timer {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.timer
+}
+
+// update replaces the Job, Timer and Options of the Task in place, keeping its run state, History and Context.
+// The old Middleware is torn down, and the new Middleware is initialized if the Task is Started. Returns true if the
+// Timer changed, in which case the next run is recalculated from it.
+func (s *Task) update(timer Timer, jobFunc func(context.Context) error, options *taskoptions) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for _, mw := range s.executationMiddleWares {
+		if td, ok := mw.(TeardownMiddleWare); ok {
+			td.Teardown(s)
+		}
+	}
+	for _, mw := range s.retryMiddlewares {
+		if td, ok := mw.(TeardownMiddleWare); ok {
+			td.Teardown(s)
+		}
+	}
+	s.jobSrcFunc = jobFunc
+	s.executationMiddleWares = options.executationmiddlewares
+	s.retryMiddlewares = options.retryMiddlewares
+	s.priority = options.priority
+	s.labels = options.labels
+	s.runTimeout = options.runTimeout
+	s.hardKill = options.hardKill
+	s.jobType = options.jobType
+	s.params = options.params
+	s.misfirePolicy = options.misfirePolicy
+	s.misfireThreshold = options.misfireThreshold
+	s.misfireLimit = options.misfireLimit
+	if s.stopScheduleSignal != nil {
+		for _, mw := range s.executationMiddleWares {
+			mw.Initilize(s)
+		}
+		for _, mw := range s.retryMiddlewares {
+			mw.Initilize(s)
+		}
+	}
+	if timerSpec(timer) == timerSpec(s.timer) && options.jitter == s.jitter {
+		return false
+	}
+	timer = bindTimer(timer, s.id, options.jitter)
+	s.jitter = options.jitter
+	if cs, ok := timer.(ClockSetter); ok {
+		cs.SetClock(s.clock)
+	}
+	s.timer = timer
+	t, _ := timer.Next()
+	s.nextRun.Set(t)
+	return true
+}
+
+// definition returns the Timer, Job and Options of the Task, and its next run
+func (s *Task) definition() *TaskDefinition {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	labels := make(map[string]string, len(s.labels))
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+	return &TaskDefinition{
+		id:      s.id,
+		timer:   s.timer,
+		jobFunc: s.jobSrcFunc,
+		nextRun: s.nextRun.Get(),
+		options: taskoptions{
+			executationmiddlewares: s.executationMiddleWares,
+			retryMiddlewares:       s.retryMiddlewares,
+			priority:               s.priority,
+			labels:                 labels,
+			runTimeout:             s.runTimeout,
+			hardKill:               s.hardKill,
+			jobType:                s.jobType,
+			params:                 s.params,
+			misfirePolicy:          s.misfirePolicy,
+			misfireThreshold:       s.misfireThreshold,
+			misfireLimit:           s.misfireLimit,
+			jitter:                 s.jitter,
+		},
+	}
+}
+
+// redefine puts back the Timer, Job, Options and next run saved by definition. Unlike update, the Middleware that is
+// put back is only initialized again if it implements TeardownMiddleWare, as it was torn down when it was replaced,
+// so the rest keeps its state.
+func (s *Task) redefine(def *TaskDefinition) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	for _, mw := range s.executationMiddleWares {
+		if td, ok := mw.(TeardownMiddleWare); ok {
+			td.Teardown(s)
+		}
+	}
+	for _, mw := range s.retryMiddlewares {
+		if td, ok := mw.(TeardownMiddleWare); ok {
+			td.Teardown(s)
+		}
+	}
+	s.jobSrcFunc = def.jobFunc
+	s.executationMiddleWares = def.options.executationmiddlewares
+	s.retryMiddlewares = def.options.retryMiddlewares
+	s.priority = def.options.priority
+	s.labels = def.options.labels
+	s.runTimeout = def.options.runTimeout
+	s.hardKill = def.options.hardKill
+	s.jobType = def.options.jobType
+	s.params = def.options.params
+	s.misfirePolicy = def.options.misfirePolicy
+	s.misfireThreshold = def.options.misfireThreshold
+	s.misfireLimit = def.options.misfireLimit
+	s.jitter = def.options.jitter
+	s.timer = def.timer
+	s.nextRun.Set(def.nextRun)
+	if s.stopScheduleSignal != nil {
+		for _, mw := range s.executationMiddleWares {
+			if _, ok := mw.(TeardownMiddleWare); ok {
+				mw.Initilize(s)
+			}
+		}
+		for _, mw := range s.retryMiddlewares {
+			if _, ok := mw.(TeardownMiddleWare); ok {
+				mw.Initilize(s)
+			}
+		}
+	}
+}
+
+//History Returns the last runs of the Task, newest first
+func (s *Task) History() []RunRecord {
+	return s.history.list()
 }
 
 func (s *Task) GetNextRun() time.Time {
@@ -331,30 +778,42 @@ func (s *Task) GetNextRun() time.Time {
 }
 
 func (s *Task) Run() {
+	s.runScheduled(s.nextRun.Get(), false)
+}
+
+// runScheduled runs the Job for the run of the Task scheduled at scheduled. The Task is rescheduled once the Job is
+// dispatched, and again after it finished only if the Retry Middleware rescheduled the Timer, as Timers such as
+// Repeating move on to their next run every time they are asked for one. A catch up run, dispatched for a misfire,
+// is not rescheduled when it is dispatched, as that was done when the misfire was handled.
+func (s *Task) runScheduled(scheduled time.Time, catchUp bool) {
+	s.wg.Add(1)
+	defer s.wg.Done()
+	rec := &RunRecord{InstanceID: uuid.New().String(), Scheduled: scheduled}
+	defer s.finishRun(rec)
 	jobResultSignal := make(chan interface{})
 	defer close(jobResultSignal)
+	retried := false
 	s.Logger.Info("Checking Pre Execution Middleware")
-	result, err := s.runPreExecutationMiddlware()
+	result, err := s.runPreExecutationMiddlware(rec)
 	switch result.Result {
 	case MWResult_Cancel:
 		s.Logger.Info("Scheduled Job run is Canceled")
-		t, _ := s.timer.Next()
-		s.nextRun.Set(t)
-		s.sendUpdateSignal(updateSignalOp_Reschedule)
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		if !catchUp {
+			s.reschedule()
+		}
 		return
 	case MWResult_Defer:
-		s.Logger.Info("Scheduled Job will be Retried")
-		s.runRetryMiddleware(true, err)
-		t, _ := s.timer.Next()
-		s.nextRun.Set(t)
-		s.sendUpdateSignal(updateSignalOp_Reschedule)
+		s.deferScheduled(rec, err, !catchUp)
 		return
 	case MWResult_NextMW:
 		s.Logger.Info("Dispatching Job")
-		go s.runJobInstance(jobResultSignal)
-		t, _ := s.timer.Next()
-		s.nextRun.Set(t)
-		s.sendUpdateSignal(updateSignalOp_Reschedule)
+		go s.runJobInstance(rec, jobResultSignal)
+		if !catchUp {
+			s.reschedule()
+		}
 	}
 	select {
 	case result := <-jobResultSignal:
@@ -367,25 +826,134 @@ func (s *Task) Run() {
 
 			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_FailedJobs), 1, []metrics.Label{{Name: "id", Value: s.id}})
 
-			mwresult := s.runPostExecutionHandler(err)
+			mwresult := s.runPostExecutionHandler(rec, err)
 
 			if mwresult.Result == MWResult_Defer {
 				/* run Retry Framework */
 				s.Logger.V(1).Info("Post Executation Middleware Retry Request")
-				s.runRetryMiddleware(false, err)
+				retried = s.runRetryMiddleware(rec, false, err)
 			}
 		} else {
 			metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_SucceededJobs), 1, []metrics.Label{{Name: "id", Value: s.id}})
-			s.runPostExecutionHandler(nil)
+			s.runPostExecutionHandler(rec, nil)
 		}
 	}
-	t, _ := s.timer.Next()
-	s.nextRun.Set(t)
+	if retried {
+		s.reschedule()
+	}
+}
+
+// runOutOfBand runs a instance of the Job with the given id outside of the Timer's schedule. Unless bypassed,
+// the Pre and Post Execution Middleware is run, but the Retry Middleware is not, as retries are scheduled by
+// the Timer and would move the next scheduled run.
+func (s *Task) runOutOfBand(instanceID string, opts *runOptions) {
+	s.wg.Add(1)
+	defer s.wg.Done()
+	rec := &RunRecord{InstanceID: instanceID, OutOfBand: true}
+	defer s.finishRun(rec)
+	defer s.takePendingRun(instanceID)
+	runlog := s.Logger.WithValues("instance", instanceID)
+	metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_RunNow), 1, []metrics.Label{{Name: "id", Value: s.id}})
+	if s.pendingRunCanceled(instanceID) {
+		// Canceled before it started, so the Job instance finishes CANCELLED without running the Middleware
+		runlog.Info("Run Now Canceled Before it Started")
+		_ = s.execJobInstance(rec)
+		return
+	}
+	if opts.bypassMiddleware {
+		runlog.Info("Running Job Now, Bypassing Middleware")
+		_ = s.execJobInstance(rec)
+		return
+	}
+	runlog.Info("Checking Pre Execution Middleware for Run Now")
+	result, err := s.runPreExecutationMiddlware(rec)
+	if result.Result != MWResult_NextMW {
+		runlog.Info("Run Now Rejected by Middleware", "result", result, "error", err)
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		return
+	}
+	if err := s.execJobInstance(rec); err != nil {
+		metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_FailedJobs), 1, []metrics.Label{{Name: "id", Value: s.id}})
+		s.runPostExecutionHandler(rec, err)
+	} else {
+		metrics.IncrCounterWithLabels(schedmetrics.GetMetricsCounterKey(schedmetrics.Metrics_Counter_SucceededJobs), 1, []metrics.Label{{Name: "id", Value: s.id}})
+		s.runPostExecutionHandler(rec, nil)
+	}
+}
+
+// finishRun adds rec to the History of the Task and saves the Task
+func (s *Task) finishRun(rec *RunRecord) {
+	s.history.add(rec)
+	s.persist()
+}
+
+// deferRun passes err to the Retry Middleware instead of running the Job scheduled at scheduled, and reschedules the
+// Task if reschedule is true
+func (s *Task) deferRun(scheduled time.Time, err error, reschedule bool) {
+	rec := &RunRecord{InstanceID: uuid.New().String(), Scheduled: scheduled}
+	defer s.finishRun(rec)
+	s.deferScheduled(rec, err, reschedule)
+}
+
+// deferScheduled passes err to the Retry Middleware for the run recorded by rec, and reschedules the Task if
+// reschedule is true
+func (s *Task) deferScheduled(rec *RunRecord, err error, reschedule bool) {
+	s.Logger.Info("Scheduled Job will be Retried")
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	s.stats.recordDefer(err)
+	s.emit(Event{Type: EventType_Deferred, InstanceID: rec.InstanceID, Scheduled: rec.Scheduled, Error: rec.Error})
+	s.runRetryMiddleware(rec, true, err)
+	if reschedule {
+		s.reschedule()
+	}
+}
+
+// reschedule sets the next run of the Task from its Timer and tells the Scheduler about it
+func (s *Task) reschedule() {
+	s.advance()
 	s.sendUpdateSignal(updateSignalOp_Reschedule)
 }
 
+// advance sets the next run of the Task from its Timer, without telling the Scheduler
+func (s *Task) advance() {
+	s.timerMx.Lock()
+	defer s.timerMx.Unlock()
+	t, _ := s.getTimer().Next()
+	s.nextRun.Set(t)
+}
+
+// timerSpec returns the Spec of the Timer of the Task, see TimerSpec
+func (s *Task) timerSpec() string {
+	s.timerMx.Lock()
+	defer s.timerMx.Unlock()
+	return timerSpec(s.getTimer())
+}
+
+// nextAfter returns the first run of the Task after t, or done if there is none or its Timer does not implement
+// Forecaster
+func (s *Task) nextAfter(t time.Time) (time.Time, bool) {
+	s.timerMx.Lock()
+	defer s.timerMx.Unlock()
+	fc, ok := s.getTimer().(Forecaster)
+	if !ok {
+		return time.Time{}, true
+	}
+	return fc.NextAfter(t)
+}
+
 func (s *Task) sendUpdateSignal(op UpdateSignalOp_Type) {
+	if s.updateSignal == nil {
+		return
+	}
 	s.Logger.V(1).Info("Sending Update Signal")
-	s.updateSignal <- updateSignalOp{id: s.id, operation: op}
-	s.Logger.V(1).Info("Sent Update Signal")
+	select {
+	case s.updateSignal <- updateSignalOp{id: s.id, operation: op}:
+		s.Logger.V(1).Info("Sent Update Signal")
+	case <-s.schedulerDone:
+		s.Logger.V(1).Info("Scheduler Shutdown, Update Signal Dropped")
+	}
 }
@@ -0,0 +1,298 @@
+package taskmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/job"
+)
+
+//TaskState The state of a Task as reported by TaskInfo
+type TaskState int
+
+const (
+	// TaskState_Stopped Task has not been Started, or has been Stopped
+	TaskState_Stopped TaskState = iota
+	// TaskState_Scheduled Task is Started and waiting for its next run
+	TaskState_Scheduled
+	// TaskState_Paused Task is Started but Paused, so it is not dispatched
+	TaskState_Paused
+	// TaskState_Running Task has at least one Job instance running
+	TaskState_Running
+)
+
+func (s TaskState) String() string {
+	switch s {
+	case TaskState_Stopped:
+		return "STOPPED"
+	case TaskState_Scheduled:
+		return "SCHEDULED"
+	case TaskState_Paused:
+		return "PAUSED"
+	case TaskState_Running:
+		return "RUNNING"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+//MarshalText Encodes the TaskState as its String
+func (s TaskState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+//UnmarshalText Decodes a TaskState encoded with MarshalText
+func (s *TaskState) UnmarshalText(text []byte) error {
+	for v := TaskState_Stopped; v <= TaskState_Running; v++ {
+		if v.String() == string(text) {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown task state %q", string(text))
+}
+
+//RunOutcome The outcome of the last run of a Task
+type RunOutcome int
+
+const (
+	// RunOutcome_None Task has not run yet
+	RunOutcome_None RunOutcome = iota
+	// RunOutcome_Succeeded Job finished without error
+	RunOutcome_Succeeded
+	// RunOutcome_Failed Job returned an error
+	RunOutcome_Failed
+	// RunOutcome_Panicked Job panicked
+	RunOutcome_Panicked
+	// RunOutcome_TimedOut Job ran for longer than its Run Timeout
+	RunOutcome_TimedOut
+	// RunOutcome_Cancelled Job was cancelled with CancelRun or CancelAllRuns
+	RunOutcome_Cancelled
+	// RunOutcome_Deferred Run was deferred to the Retry Middleware without running the Job
+	RunOutcome_Deferred
+)
+
+func (o RunOutcome) String() string {
+	switch o {
+	case RunOutcome_None:
+		return "NONE"
+	case RunOutcome_Succeeded:
+		return "SUCCEEDED"
+	case RunOutcome_Failed:
+		return "FAILED"
+	case RunOutcome_Panicked:
+		return "PANICKED"
+	case RunOutcome_TimedOut:
+		return "TIMEDOUT"
+	case RunOutcome_Cancelled:
+		return "CANCELLED"
+	case RunOutcome_Deferred:
+		return "DEFERRED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+//MarshalText Encodes the RunOutcome as its String
+func (o RunOutcome) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+//UnmarshalText Decodes a RunOutcome encoded with MarshalText
+func (o *RunOutcome) UnmarshalText(text []byte) error {
+	for v := RunOutcome_None; v <= RunOutcome_Deferred; v++ {
+		if v.String() == string(text) {
+			*o = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown run outcome %q", string(text))
+}
+
+//TaskInfo A point in time, read only, snapshot of the state of a Task
+type TaskInfo struct {
+	ID    string
+	State TaskState
+	// Job type and params, if the Task was added with Scheduler.AddJob
+	JobType string
+	Params  json.RawMessage
+	// Spec of the Timer, if it implements TimerSpec
+	Timer string
+	// Zero if the Task has no next run
+	NextRun    time.Time
+	LastStart  time.Time
+	LastFinish time.Time
+	// Empty if the last run did not fail
+	LastError   string
+	LastOutcome RunOutcome
+	// Number of Job runs, failed runs, retries requested by Retry Middleware and deferred runs
+	Runs     uint64
+	Failures uint64
+	Retries  uint64
+	Defers   uint64
+	// IDs of the running Job instances, sorted
+	ActiveInstances []string
+	// Type names of the Execution Middleware, followed by the Retry Middleware, in the order they run
+	Middlewares []string
+	Labels      map[string]string
+	Priority    int
+}
+
+// taskStats records the outcome of the runs of a Task for TaskInfo
+type taskStats struct {
+	mx          sync.Mutex
+	lastStart   time.Time
+	lastFinish  time.Time
+	lastError   string
+	lastOutcome RunOutcome
+	runs        uint64
+	failures    uint64
+	retries     uint64
+	defers      uint64
+}
+
+func (ts *taskStats) recordStart(at time.Time) {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+	ts.lastStart = at
+	ts.runs++
+}
+
+func (ts *taskStats) recordFinish(at time.Time, state job.State, err error) {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+	ts.lastFinish = at
+	ts.lastError = ""
+	if err != nil {
+		ts.lastError = err.Error()
+		ts.failures++
+	}
+	switch state {
+	case job.FINISHED:
+		ts.lastOutcome = RunOutcome_Succeeded
+	case job.PANICKED:
+		ts.lastOutcome = RunOutcome_Panicked
+	case job.TIMEDOUT, job.ABANDONED:
+		ts.lastOutcome = RunOutcome_TimedOut
+	case job.CANCELLED:
+		ts.lastOutcome = RunOutcome_Cancelled
+	default:
+		ts.lastOutcome = RunOutcome_Failed
+	}
+}
+
+func (ts *taskStats) recordDefer(err error) {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+	ts.defers++
+	ts.lastOutcome = RunOutcome_Deferred
+	ts.lastError = ""
+	if err != nil {
+		ts.lastError = err.Error()
+	}
+}
+
+func (ts *taskStats) recordRetry() {
+	ts.mx.Lock()
+	defer ts.mx.Unlock()
+	ts.retries++
+}
+
+//Describe Returns a TaskInfo snapshot of the Task
+func (s *Task) Describe() TaskInfo {
+	s.mx.RLock()
+	jobType, params := s.jobType, s.params
+	s.mx.RUnlock()
+	info := TaskInfo{
+		ID:              s.id,
+		JobType:         jobType,
+		Params:          params,
+		Timer:           s.timerSpec(),
+		NextRun:         s.GetNextRun(),
+		ActiveInstances: s.activeJobs.ids(),
+		Labels:          s.GetLabels(),
+		Priority:        s.GetPriority(),
+	}
+	switch {
+	case len(info.ActiveInstances) > 0:
+		info.State = TaskState_Running
+	case !s.isStarted():
+		info.State = TaskState_Stopped
+	case s.IsPaused():
+		info.State = TaskState_Paused
+	default:
+		info.State = TaskState_Scheduled
+	}
+	executationMiddleWares, retryMiddlewares := s.middlewares()
+	for _, mw := range executationMiddleWares {
+		info.Middlewares = append(info.Middlewares, fmt.Sprintf("%T", mw))
+	}
+	for _, mw := range retryMiddlewares {
+		info.Middlewares = append(info.Middlewares, fmt.Sprintf("%T", mw))
+	}
+
+	s.stats.mx.Lock()
+	defer s.stats.mx.Unlock()
+	info.LastStart = s.stats.lastStart
+	info.LastFinish = s.stats.lastFinish
+	info.LastError = s.stats.lastError
+	info.LastOutcome = s.stats.lastOutcome
+	info.Runs = s.stats.runs
+	info.Failures = s.stats.failures
+	info.Retries = s.stats.retries
+	info.Defers = s.stats.defers
+	return info
+}
+
+//Describe Returns a TaskInfo snapshot of the Schedule with the given id. Return error if no Schedule with the given
+//id exist.
+func (s *Scheduler) Describe(id string) (TaskInfo, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return TaskInfo{}, err
+	}
+	return schedule.Describe(), nil
+}
+
+//Forecast Returns up to count of the next run times of the Schedule with the given id, starting with its next run,
+//assuming it stays Started and is not Paused or rescheduled by Middleware. Only the next run is returned if its Timer does
+//not implement Forecaster. Return error if no Schedule with the given id exist.
+func (s *Scheduler) Forecast(id string, count int) ([]time.Time, error) {
+	schedule, err := s.GetSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	next := schedule.GetNextRun()
+	if count <= 0 || next.IsZero() {
+		return nil, nil
+	}
+	runs := []time.Time{next}
+	for len(runs) < count {
+		var done bool
+		if next, done = schedule.nextAfter(next); done {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
+
+//List Returns a TaskInfo snapshot of every Schedule in the Scheduler, sorted by ID
+func (s *Scheduler) List() []TaskInfo {
+	s.mx.RLock()
+	tasks := make([]*Task, 0, len(s.tasks))
+	for _, schedule := range s.tasks {
+		tasks = append(tasks, schedule)
+	}
+	s.mx.RUnlock()
+
+	infos := make([]TaskInfo, 0, len(tasks))
+	for _, schedule := range tasks {
+		infos = append(infos, schedule.Describe())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
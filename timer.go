@@ -2,24 +2,48 @@ package taskmanager
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Fishwaldo/go-taskmanager/clock"
 	"github.com/gorhill/cronexpr"
 )
 
 //Timer is an Interface for a Timer object that is used by a Schedule to determine when to run the next run of a job.
 // Timer need to implement the Next() method returning the time of the next Job run. Timer indicates that no jobs shall
 // be scheduled anymore by returning done == true. The `next time.Time` returned with `done bool` == true IS IGNORED.
-// Next() shall not return time in the past. Time in the past is reset to time.Now() at evaluation time in the scheduler.
+// Next() shall not return time in the past. Time in the past is reset to the Clock's Now() at evaluation time in the scheduler.
 type Timer interface {
 	Next() (next time.Time, done bool)
 	Reschedule(delay time.Duration)
 }
 
+//TimerSpec is an optional Interface a Timer can implement to describe itself as a string that ParseTimer and
+//Scheduler.AddJob can recreate it from, so Tasks using it can be saved in a Store. All the Timers in this package implement it.
+type TimerSpec interface {
+	Spec() string
+}
+
+//ClockSetter is an optional Interface a Timer can implement to use the Clock the Task it is added to was
+//created with. All the Timers in this package implement it.
+type ClockSetter interface {
+	SetClock(c clock.Clock)
+}
+
+//Forecaster is an optional Interface a Timer can implement to tell when it would fire after a given time, without
+//changing its state, so Scheduler.Forecast can list the upcoming runs of a Task. All the Timers in this package implement it.
+type Forecaster interface {
+	NextAfter(t time.Time) (next time.Time, done bool)
+}
+
 //Once A timer that run ONCE after an optional specific delay.
 type Once struct {
 	delay time.Duration
+	at    time.Time
 	done  bool
+	clock clock.Clock
+	// spec is the Spec the Timer was created with, which stays the same when it is rescheduled
+	spec string
 }
 
 //NewOnce Return a timer that trigger ONCE after `d` delay as soon as Timer is inquired for the next Run.
@@ -30,21 +54,18 @@ func NewOnce(d time.Duration) (*Once, error) {
 	}
 	return &Once{
 		delay: d,
+		clock: clock.New(),
+		spec:  "@after " + d.String(),
 	}, nil
 }
 
 // NewOnceTime Return a timer that trigger ONCE at `t` time.Time.
 //If `t` is in the past at inquery time, timer will NOT run.
 func NewOnceTime(t time.Time) (*Once, error) {
-	remaining := time.Until(t)
-	if remaining < 0 {
-		return &Once{
-			delay: remaining,
-			done:  true,
-		}, nil
-	}
 	return &Once{
-		delay: remaining,
+		at:    t,
+		clock: clock.New(),
+		spec:  "@at " + t.Format(time.RFC3339Nano),
 	}, nil
 }
 
@@ -52,23 +73,51 @@ func NewOnceTime(t time.Time) (*Once, error) {
 func (o *Once) Next() (time.Time, bool) {
 	if !o.done {
 		o.done = true
-		return time.Now().Add(o.delay), false
+		now := o.clock.Now()
+		if !o.at.IsZero() {
+			if o.at.Before(now) {
+				return time.Time{}, o.done
+			}
+			return o.at, false
+		}
+		return now.Add(o.delay), false
 	}
 	return time.Time{}, o.done
 }
 
 func (o *Once) Reschedule(d time.Duration) {
 	o.delay = d
+	o.at = time.Time{}
 	if o.done {
 		o.done = false
 	}
 }
 
+//Spec Returns "@at <RFC3339 time>" for a Timer created with NewOnceTime, or "@after <delay>", even once it was
+//rescheduled
+func (o *Once) Spec() string {
+	return o.spec
+}
+
+//NextAfter Returns the time of a Timer created with NewOnceTime if it is after t, otherwise done
+func (o *Once) NextAfter(t time.Time) (time.Time, bool) {
+	if !o.at.IsZero() && o.at.After(t) {
+		return o.at, false
+	}
+	return time.Time{}, true
+}
+
+//SetClock Use c to determine the current time
+func (o *Once) SetClock(c clock.Clock) {
+	o.clock = c
+}
+
 //Fixed A Timer that fires at a fixed duration intervals
 type Fixed struct {
 	duration time.Duration
 	next     time.Time
 	delay    time.Duration
+	clock    clock.Clock
 }
 
 //NewFixed Returns Fixed Timer; A Timer that fires at a fixed duration intervals.
@@ -78,19 +127,19 @@ func NewFixed(duration time.Duration) (*Fixed, error) {
 	}
 	return &Fixed{
 		duration: duration,
-		next:     time.Now().Add(duration),
+		clock:    clock.New(),
 	}, nil
 }
 
 //Next Return Next fire time.
 func (f *Fixed) Next() (time.Time, bool) {
-	now := time.Now()
+	now := f.clock.Now()
 	if f.delay > 0 {
 		next := now.Add(f.delay)
 		f.delay = 0
 		return next, false
 	}
-	f.next = time.Now().Add(f.duration)
+	f.next = now.Add(f.duration)
 	return f.next, false
 }
 
@@ -98,33 +147,274 @@ func (f *Fixed) Reschedule(t time.Duration) {
 	f.delay = t
 }
 
+//Spec Returns "@every <duration>"
+func (f *Fixed) Spec() string {
+	return "@every " + f.duration.String()
+}
+
+//NextAfter Returns t plus the interval of the Timer
+func (f *Fixed) NextAfter(t time.Time) (time.Time, bool) {
+	return t.Add(f.duration), false
+}
+
+//SetClock Use c to determine the current time
+func (f *Fixed) SetClock(c clock.Clock) {
+	f.clock = c
+}
+
 //Cron A Timer that fires at according to a cron expression.
 //All expresion supported by `https://github.com/gorhill/cronexpr` are supported.
+//
+//The expression is evaluated in the wall clock of its location, given by NewCronInLocation or a CRON_TZ= prefix, or
+//otherwise of the time returned by the Clock. Across a daylight saving change:
+//
+//	a run at a local time that is skipped when the clocks go forward runs once, at the instant they go forward, and
+//	several skipped runs, such as every 15 minutes, run only once
+//	a run at a local time that is repeated when the clocks go back runs once, at the first occurrence of it
 type Cron struct {
 	expression cronexpr.Expression
+	spec       string
+	expr       string
+	location   *time.Location
+	hashed     bool
 	delay      time.Duration
+	clock      clock.Clock
 }
 
+// maxCronSkips is the most times in a row a Cron skips a local time it already passed, before giving up
+const maxCronSkips = 1000
+
 //NewCron returns a Timer that fires at according to a cron expression.
-//All expresion supported by `https://github.com/gorhill/cronexpr` are supported.
+//All expresion supported by `https://github.com/gorhill/cronexpr` are supported, as well as H in any field but the
+//year, which is replaced with a value worked out by hashing the ID of the Task the Cron is added to, so Tasks with the
+//same expression are spread out, and a Task always runs at the same time (see TaskBinder):
+//
+//	H        a value in the range of the field, with the day of month limited to 1-28
+//	H(a-b)   a value between a and b
+//	H/n      every n, starting at a value between the start of the range and n
+//	H(a-b)/n every n between a and b, starting at a value between a and a+n
+//
+//For example "H H(0-5) * * *" runs once a day, at a minute of the night that depends on the Task.
+//
+//The expression can start with CRON_TZ=<zone> or TZ=<zone>, with a IANA time zone such as Europe/Berlin, to be
+//evaluated in that time zone, see Cron.
 func NewCron(cronExpression string) (*Cron, error) {
-	expression, err := cronexpr.Parse(cronExpression)
+	location, expr, err := cronLocation(cronExpression)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCron(expr, location)
+	if err != nil {
+		return nil, err
+	}
+	c.spec = cronExpression
+	return c, nil
+}
+
+// cronLocation splits the CRON_TZ= or TZ= prefix off the cron expression expr, returning the location it names, or
+// nil if there is none
+func cronLocation(expr string) (*time.Location, string, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+		return nil, expr, nil
+	}
+	fields := strings.SplitN(expr, " ", 2)
+	if len(fields) != 2 {
+		return nil, "", fmt.Errorf("cron expression invalid: missing expression after %s", fields[0])
+	}
+	loc, err := time.LoadLocation(fields[0][strings.Index(fields[0], "=")+1:])
+	if err != nil {
+		return nil, "", fmt.Errorf("cron expression invalid: %w", err)
+	}
+	return loc, strings.TrimSpace(fields[1]), nil
+}
+
+//NewCronInLocation returns a Timer that fires according to a cron expression, evaluated in the wall clock of loc.
+//The expression can not have a CRON_TZ= prefix, see NewCron.
+func NewCronInLocation(cronExpression string, loc *time.Location) (*Cron, error) {
+	if loc == nil {
+		return nil, fmt.Errorf("cron expression invalid: missing location")
+	}
+	prefixed, expr, err := cronLocation(cronExpression)
+	if err != nil || prefixed != nil {
+		return nil, fmt.Errorf("cron expression invalid: %q already has a time zone", cronExpression)
+	}
+	c, err := newCron(expr, loc)
+	if err != nil {
+		return nil, err
+	}
+	c.spec = "CRON_TZ=" + loc.String() + " " + expr
+	return c, nil
+}
+
+// newCron parses expr, without a time zone prefix, to be evaluated in location
+func newCron(expr string, location *time.Location) (*Cron, error) {
+	expanded, hashed, err := expandCronHash(expr, "")
+	if err != nil {
+		return nil, fmt.Errorf("cron expression invalid: %w", err)
+	}
+	expression, err := cronexpr.Parse(expanded)
 	if err != nil {
 		return nil, fmt.Errorf("cron expression invalid: %w", err)
 	}
-	return &Cron{expression: *expression}, nil
+	return &Cron{expression: *expression, expr: expr, location: location, hashed: hashed, clock: clock.New()}, nil
 }
 
 //Next Return Next fire time.
 func (c *Cron) Next() (time.Time, bool) {
+	now := c.clock.Now()
 	if c.delay > 0 {
-		next := time.Now().Add(c.delay)
+		next := now.Add(c.delay)
 		c.delay = 0
 		return next, false
 	}
-	return c.expression.Next(time.Now()), false
+	return c.next(now), false
 }
 
 func (c *Cron) Reschedule(d time.Duration) {
 	c.delay = d
 }
+
+//Spec Returns the cron expression
+func (c *Cron) Spec() string {
+	return c.spec
+}
+
+//Location Returns the time zone the expression is evaluated in, or nil if it is the one of the Clock
+func (c *Cron) Location() *time.Location {
+	return c.location
+}
+
+//NextAfter Returns the first time after t matching the cron expression
+func (c *Cron) NextAfter(t time.Time) (time.Time, bool) {
+	next := c.next(t)
+	return next, next.IsZero()
+}
+
+// next returns the first time after from matching the expression, in the wall clock of the location of c, or zero if
+// there is none. cronexpr does not handle daylight saving changes, so the expression is evaluated on the wall clock
+// as if it was UTC, and the matching wall clock time is then found in the location.
+func (c *Cron) next(from time.Time) time.Time {
+	loc := c.location
+	if loc == nil {
+		loc = from.Location()
+	}
+	from = from.In(loc)
+	wall := wallClock(from)
+	for i := 0; i < maxCronSkips; i++ {
+		wall = c.expression.Next(wall)
+		if wall.IsZero() {
+			return wall
+		}
+		// A repeated local time whose first occurrence already passed is skipped
+		if t := inLocation(wall, loc); t.After(from) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// wallClock returns the wall clock of t, as a time in UTC
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// inLocation returns the first instant the wall clock of loc reads wall, given as a time in UTC. If the clocks of loc
+// skip wall, it returns the instant they skipped it.
+func inLocation(wall time.Time, loc *time.Location) time.Time {
+	// The offsets of loc before and after wall, assuming it changes at most once around it
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := wall.Add(24 * time.Hour).In(loc).Zone()
+	first := wall.Add(-time.Duration(before) * time.Second).In(loc)
+	second := wall.Add(-time.Duration(after) * time.Second).In(loc)
+	if second.Before(first) {
+		first, second = second, first
+	}
+	for _, t := range []time.Time{first, second} {
+		if wallClock(t).Equal(wall) {
+			return t
+		}
+	}
+
+	// wall was skipped, so search for the change of offset between the two
+	_, offset := second.Zone()
+	for second.Sub(first) > 1 {
+		mid := first.Add(second.Sub(first) / 2)
+		if _, o := mid.Zone(); o == offset {
+			second = mid
+		} else {
+			first = mid
+		}
+	}
+	return second
+}
+
+//BindTask Work out the values of H in the expression from the Task id
+func (c *Cron) BindTask(id string) {
+	if !c.hashed {
+		return
+	}
+	// The expression was validated by NewCron, and only the values of H change
+	expanded, _, err := expandCronHash(c.expr, id)
+	if err != nil {
+		return
+	}
+	if expression, err := cronexpr.Parse(expanded); err == nil {
+		c.expression = *expression
+	}
+}
+
+//SetClock Use c to determine the current time
+func (c *Cron) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+//ParseTimer Returns the Timer described by spec, so Timers can be given as strings in configuration files and APIs.
+//It accepts the Spec of every Timer in this package (see TimerSpec), as well as:
+//
+//	0 3 * * *                          a cron expression, see NewCron
+//	0 3 * * * 2030                     a cron expression with 6 fields, the last being the year
+//	0 0 */5 * * * *                    a cron expression with 7 fields, the first being the seconds
+//	CRON_TZ=Europe/Berlin 0 3 * * *    a cron expression evaluated in a time zone
+//	@hourly, @daily, @weekly...        a cron descriptor
+//	@every 90s                         a Fixed Timer
+//	@after 10s                         a Once Timer, see NewOnce
+//	@at 2026-11-01T03:00:00Z           a Once Timer at a RFC3339 time, see NewOnceTime
+//	R5/2026-01-01T00:00:00Z/PT1H       a ISO 8601 repeating interval, see ParseRepeating
+func ParseTimer(spec string) (Timer, error) {
+	spec = strings.TrimSpace(spec)
+	var timer Timer
+	var err error
+	switch {
+	case strings.HasPrefix(spec, "@every "):
+		d, perr := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if perr != nil {
+			return nil, fmt.Errorf("invalid timer spec %q: %w", spec, perr)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid timer spec %q, interval must be > 0", spec)
+		}
+		timer, err = NewFixed(d)
+	case strings.HasPrefix(spec, "@after "):
+		d, perr := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@after ")))
+		if perr != nil {
+			return nil, fmt.Errorf("invalid timer spec %q: %w", spec, perr)
+		}
+		timer, err = NewOnce(d)
+	case strings.HasPrefix(spec, "@at "):
+		t, perr := time.Parse(time.RFC3339Nano, strings.TrimSpace(strings.TrimPrefix(spec, "@at ")))
+		if perr != nil {
+			return nil, fmt.Errorf("invalid timer spec %q: %w", spec, perr)
+		}
+		timer, err = NewOnceTime(t)
+	case strings.HasPrefix(spec, "R"):
+		timer, err = ParseRepeating(spec)
+	default:
+		timer, err = NewCron(spec)
+	}
+	// The constructors return a typed nil pointer with their error, which must not be returned as a non-nil Timer
+	if err != nil {
+		return nil, err
+	}
+	return timer, nil
+}
@@ -1,38 +1,47 @@
 package taskmanager
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/Fishwaldo/go-taskmanager/clock"
 )
 
+var testTime = time.Date(2021, time.November, 1, 0, 0, 0, 0, time.UTC)
+
 func TestTimerOnce(t *testing.T) {
+	fc := clock.NewFake(testTime)
 	timer, err := NewOnce(1 * time.Second)
 	if err != nil {
 		t.Errorf("NewOnce Timer Returned Error %s", err.Error())
 	}
+	timer.SetClock(fc)
 	next, run := timer.Next()
-	if !next.Round(time.Second).Equal(time.Now().Add(1 * time.Second).Round(time.Second)) {
-		t.Errorf("next != time.Now().Add(1 * time.Second) - %s - %s", next.Round(time.Second), time.Now().Add(1 * time.Second).Round(time.Second))
+	if !next.Equal(fc.Now().Add(1 * time.Second)) {
+		t.Errorf("next != Now().Add(1 * time.Second) - %s - %s", next, fc.Now().Add(1*time.Second))
 	}
 	if run {
 		t.Errorf("Done is Not True")
 	}
-	next, run = timer.Next()
+	_, run = timer.Next()
 	if !run {
 		t.Errorf("Done is Not True after second run")
 	}
 
+	fc.Advance(5 * time.Second)
 	timer.Reschedule(2 * time.Second)
 
 	next, run = timer.Next()
-	if !next.Round(time.Second).Equal(time.Now().Add(2 * time.Second).Round(time.Second)) {
-		t.Errorf("Reschedule next != time.Now().Add(2 * time.Second) - %s - %s", next.Round(time.Second), time.Now().Add(2 * time.Second).Round(time.Second))
+	if !next.Equal(fc.Now().Add(2 * time.Second)) {
+		t.Errorf("Reschedule next != Now().Add(2 * time.Second) - %s - %s", next, fc.Now().Add(2*time.Second))
 	}
 
 	if run {
 		t.Errorf("Done is Not True")
 	}
-	next, run = timer.Next()
+	_, run = timer.Next()
 	if !run {
 		t.Errorf("Done is Not True after second run")
 	}
@@ -47,58 +56,69 @@ func TestTimerOnceInvalidDuration(t *testing.T) {
 }
 
 func TestTimerOnceTime(t *testing.T) {
-	timer, err := NewOnceTime(time.Now().Add(1 * time.Second))
+	fc := clock.NewFake(testTime)
+	timer, err := NewOnceTime(fc.Now().Add(1 * time.Second))
 	if err != nil {
 		t.Errorf("NewOnceTime Timer Returned Error %s", err.Error())
 	}
+	timer.SetClock(fc)
 	next, run := timer.Next()
-	if !next.Round(time.Second).Equal(time.Now().Add(1 * time.Second).Round(time.Second)) {
-		t.Errorf("NewOnceTime next != time.Now().Add(1 * time.Second) - %s - %s", next.Round(time.Second), time.Now().Add(1 * time.Second).Round(time.Second))
+	if !next.Equal(fc.Now().Add(1 * time.Second)) {
+		t.Errorf("NewOnceTime next != Now().Add(1 * time.Second) - %s - %s", next, fc.Now().Add(1*time.Second))
 	}
 	if run {
 		t.Errorf("NewOnceTime Done is Not True")
 	}
-	next, run = timer.Next()
+	_, run = timer.Next()
 	if !run {
 		t.Errorf("NewOnceTime Done is Not True after second run")
-	}	
+	}
 }
 
 func TestTimerOnceTimeInvalidDuration(t *testing.T) {
-	timer, err := NewOnceTime(time.Now().Add(-1 * time.Hour))
+	fc := clock.NewFake(testTime)
+	timer, err := NewOnceTime(fc.Now().Add(-1 * time.Hour))
 	if err != nil {
 		t.Errorf("NewOnce Timer Returned Error %s", err.Error())
 	}
+	timer.SetClock(fc)
 	next, run := timer.Next()
 	if !run {
 		t.Errorf("NewOnceTime Done is True")
 	}
-	if !next.Round(time.Second).Equal(time.Time{}.Round(time.Second)) {
-		t.Errorf("NewOnceTime next != invalid Time - %s - %s", next.Round(time.Second), time.Now().Add(1 * time.Second).Round(time.Second))
+	if !next.IsZero() {
+		t.Errorf("NewOnceTime next != invalid Time - %s", next)
 	}
 
 }
 
 func TestTimerFixed(t *testing.T) {
+	fc := clock.NewFake(testTime)
 	timer, err := NewFixed(10 * time.Second)
 	if err != nil {
 		t.Errorf("NewFixed Timer Returned Error: %s", err.Error())
 	}
+	timer.SetClock(fc)
 	next, run := timer.Next()
-	if !next.Round(time.Second).Equal(time.Now().Add(10 * time.Second).Round(time.Second)) {
-		t.Errorf("FixedTimer next != time.Now().Add(10 *time.Second) - %s - %s", next.Round(time.Second), time.Now().Add(10 * time.Second).Round(time.Second))
+	if !next.Equal(fc.Now().Add(10 * time.Second)) {
+		t.Errorf("FixedTimer next != Now().Add(10 *time.Second) - %s - %s", next, fc.Now().Add(10*time.Second))
 	}
 	if run {
 		t.Errorf("FixedTimer Run is False")
 	}
+	fc.Advance(10 * time.Second)
 	timer.Reschedule(2 * time.Second)
 	next, run = timer.Next()
-	if !next.Round(time.Second).Equal(time.Now().Add(2 * time.Second).Round(time.Second)) {
-		t.Errorf("FixedTimer next != time.Now().Add(2 *time.Second) - %s - %s", next.Round(time.Second), time.Now().Add(10 * time.Second).Round(time.Second))
+	if !next.Equal(fc.Now().Add(2 * time.Second)) {
+		t.Errorf("FixedTimer next != Now().Add(2 *time.Second) - %s - %s", next, fc.Now().Add(2*time.Second))
 	}
 	if run {
 		t.Errorf("FixedTimer Run is False")
 	}
+	next, _ = timer.Next()
+	if !next.Equal(fc.Now().Add(10 * time.Second)) {
+		t.Errorf("FixedTimer next != Now().Add(10 *time.Second) after Reschedule - %s - %s", next, fc.Now().Add(10*time.Second))
+	}
 }
 
 func TestTimerFixedInvalidDuration(t *testing.T) {
@@ -108,34 +128,315 @@ func TestTimerFixedInvalidDuration(t *testing.T) {
 	}
 }
 
-
-// XXX TODO: Fix TestTimerCron to handle timezone tests
-// func TestTimerCron(t *testing.T) {
-// 	timer, err := NewCron("5 4 1 12 2")
-// 	if err != nil {
-// 		t.Errorf("Crontimer Timer Returned Error: %s", err.Error())
-// 	}
-// 	next, run := timer.Next()
-// 	test, _ := time.Parse(time.RFC3339, "2021-12-01T04:05:00+08:00")
-// 	if !next.Round(time.Second).Equal(test.Round(time.Second)) {
-// 		t.Errorf("Crontimer next != 2021-12-01T04:05:00+08:00 - %s - %s", next.Round(time.Second), time.Now().Add(10 * time.Second).Round(time.Second))
-// 	}
-// 	if run {
-// 		t.Errorf("Crontimer Run is False")
-// 	}
-// 	timer.Reschedule(10 * time.Second)
-// 	next, run = timer.Next()
-// 	if !next.Round(time.Second).Equal(time.Now().Add(10 * time.Second).Round(time.Second)) {
-// 		t.Errorf("Crontimer next != time.Now().Add(10 *time.Second) - %s - %s", next.Round(time.Second), time.Now().Add(10 * time.Second).Round(time.Second))
-// 	}
-// 	if run {
-// 		t.Errorf("Crontimer Run is False")
-// 	}
-// }
+func TestTimerCron(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	timer, err := NewCron("5 4 1 12 2")
+	if err != nil {
+		t.Errorf("Crontimer Timer Returned Error: %s", err.Error())
+	}
+	timer.SetClock(fc)
+	next, run := timer.Next()
+	test, _ := time.Parse(time.RFC3339, "2021-12-01T04:05:00Z")
+	if !next.Equal(test) {
+		t.Errorf("Crontimer next != 2021-12-01T04:05:00Z - %s", next)
+	}
+	if run {
+		t.Errorf("Crontimer Run is False")
+	}
+	timer.Reschedule(10 * time.Second)
+	next, run = timer.Next()
+	if !next.Equal(fc.Now().Add(10 * time.Second)) {
+		t.Errorf("Crontimer next != Now().Add(10 *time.Second) - %s - %s", next, fc.Now().Add(10*time.Second))
+	}
+	if run {
+		t.Errorf("Crontimer Run is False")
+	}
+}
 
 func TestTimerCronInvalidFormat(t *testing.T) {
 	_, err := NewCron("5 4 1 14 2")
 	if err == nil {
 		t.Errorf("NewOnce Timer Did Not Returned Error")
 	}
-}
\ No newline at end of file
+}
+
+func TestTimerSpec(t *testing.T) {
+	once, _ := NewOnce(5 * time.Second)
+	at, _ := NewOnceTime(testTime)
+	fixed, _ := NewFixed(1 * time.Hour)
+	cron, _ := NewCron("0 3 * * *")
+	repeating, _ := NewRepeating(testTime, 90*time.Minute, 5)
+	monthly, _ := ParseRepeating("R/2021-11-01T03:00:00+01:00/P1MT1.5S")
+	daily, _ := NewCron("0 3 * * * *")
+	yearly, _ := NewCron("0 3 * * * 2030")
+	seconds, _ := NewCron("30 0 */5 * * * *")
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	inLocation, _ := NewCronInLocation("0 3 * * *", berlin)
+	prefixed, _ := NewCron("CRON_TZ=Europe/Berlin 0 3 * * *")
+	hashed, _ := NewCron("H H(0-5) * * *")
+	hashed.BindTask("backup")
+	jittered := bindTimer(cron, "backup", 10*time.Minute)
+	timers := []Timer{once, at, fixed, cron, repeating, monthly, daily, yearly, seconds, inLocation, prefixed, hashed, jittered}
+	for _, timer := range timers {
+		spec := timer.(TimerSpec).Spec()
+		parsed, err := ParseTimer(spec)
+		if err != nil {
+			t.Errorf("ParseTimer(%q) Returned Error: %s", spec, err.Error())
+			continue
+		}
+		if got := parsed.(TimerSpec).Spec(); got != spec {
+			t.Errorf("ParseTimer(%q) has Spec %q", spec, got)
+		}
+		// The parsed Timer runs at the same times, once bound to the same Task
+		if _, ok := timer.(*jitterTimer); ok {
+			parsed = bindTimer(parsed, "backup", 10*time.Minute)
+		} else if timer == hashed {
+			parsed.(TaskBinder).BindTask("backup")
+		}
+		want, got := timer.(Forecaster), parsed.(Forecaster)
+		for i, tm := 0, testTime; i < 5; i++ {
+			next, done := want.NextAfter(tm)
+			if again, againDone := got.NextAfter(tm); !again.Equal(next) || againDone != done {
+				t.Errorf("ParseTimer(%q) runs after %s at %s, want %s", spec, tm, again, next)
+				break
+			}
+			if done {
+				break
+			}
+			tm = next
+		}
+	}
+
+	// A retry does not change the Spec, so the Task is not seen as having a new Timer
+	for _, timer := range []Timer{once, at} {
+		spec := timer.(TimerSpec).Spec()
+		timer.Reschedule(1 * time.Minute)
+		if got := timer.(TimerSpec).Spec(); got != spec {
+			t.Errorf("Rescheduled Timer has Spec %q, not %q", got, spec)
+		}
+	}
+}
+
+func TestTimerCronHash(t *testing.T) {
+	next := func(expr string, id string) []time.Time {
+		t.Helper()
+		timer, err := NewCron(expr)
+		if err != nil {
+			t.Fatalf("NewCron(%q) Returned Error %s", expr, err.Error())
+		}
+		timer.BindTask(id)
+		var runs []time.Time
+		for tm := testTime; len(runs) < 4; {
+			tm, _ = timer.NextAfter(tm)
+			runs = append(runs, tm)
+		}
+		return runs
+	}
+	a, b := next("H H(0-5) * * *", "backup"), next("H H(0-5) * * *", "export")
+	if !a[0].Equal(next("H H(0-5) * * *", "backup")[0]) {
+		t.Errorf("The same Task runs at %s and %s", a[0], next("H H(0-5) * * *", "backup")[0])
+	}
+	if a[0].Equal(b[0]) {
+		t.Errorf("Different Tasks both run at %s", a[0])
+	}
+	for _, runs := range [][]time.Time{a, b} {
+		if runs[0].Hour() > 5 || runs[1].Sub(runs[0]) != 24*time.Hour {
+			t.Errorf("H H(0-5) * * * runs at %v", runs)
+		}
+	}
+	runs := next("H/15 * * * *", "backup")
+	if runs[0].Minute() >= 15 || runs[1].Sub(runs[0]) != 15*time.Minute || runs[3].Sub(runs[2]) != 15*time.Minute {
+		t.Errorf("H/15 * * * * runs at %v", runs)
+	}
+	for _, expr := range []string{"H(5-70) * * * *", "H * * * * H", "H(5) * * * *", "H/x * * * *"} {
+		if _, err := NewCron(expr); err == nil {
+			t.Errorf("NewCron(%q) did not return an error", expr)
+		}
+	}
+}
+
+func TestTimerJitter(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	fixed, _ := NewFixed(1 * time.Hour)
+	timer := bindTimer(fixed, "backup", 10*time.Minute)
+	timer.(ClockSetter).SetClock(fc)
+	first, _ := timer.Next()
+	offset := first.Sub(testTime.Add(1 * time.Hour))
+	if offset < 0 || offset >= 10*time.Minute {
+		t.Fatalf("Jitter offset is %s", offset)
+	}
+	fc.Advance(first.Sub(testTime))
+	if second, _ := timer.Next(); second.Sub(first) != 1*time.Hour {
+		t.Errorf("Jittered interval is %s, not 1h", second.Sub(first))
+	}
+
+	// The same Task always has the same offset
+	cron, _ := NewCron("0 * * * *")
+	timer = bindTimer(cron, "backup", 10*time.Minute)
+	if next, _ := timer.(Forecaster).NextAfter(testTime); !next.Equal(testTime.Add(offset)) {
+		t.Errorf("Jittered Cron runs at %s, not %s", next, testTime.Add(offset))
+	}
+}
+
+func TestTaskJitter(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	cron, _ := NewCron("0 * * * *")
+	task := NewSchedule(context.Background(), "backup", cron, func(ctx context.Context) {}, WithClock(fc), WithJitter(10*time.Minute))
+	again, _ := NewCron("0 * * * *")
+	if other := NewSchedule(context.Background(), "backup", again, func(ctx context.Context) {}, WithClock(fc), WithJitter(10*time.Minute)); !other.GetNextRun().Equal(task.GetNextRun()) {
+		t.Errorf("The same Task runs at %s and %s", task.GetNextRun(), other.GetNextRun())
+	}
+	offset := task.GetNextRun().Sub(testTime)
+	if offset <= 0 || offset >= 10*time.Minute {
+		t.Fatalf("Jittered Task runs at %s", task.GetNextRun())
+	}
+	fc.Advance(offset)
+	if next, _ := task.getTimer().Next(); !next.Equal(testTime.Add(1*time.Hour + offset)) {
+		t.Errorf("Jittered Task runs next at %s, not %s", next, testTime.Add(1*time.Hour+offset))
+	}
+}
+
+func TestTimerCronLocation(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database not available: %s", err.Error())
+	}
+	runs := func(expr string, from time.Time, n int) []string {
+		t.Helper()
+		timer, err := NewCron(expr)
+		if err != nil {
+			t.Fatalf("NewCron(%q) Returned Error %s", expr, err.Error())
+		}
+		var out []string
+		for tm := from; len(out) < n; {
+			tm, _ = timer.NextAfter(tm)
+			out = append(out, tm.Format("01-02 15:04 MST"))
+		}
+		return out
+	}
+	tests := []struct {
+		expr string
+		from time.Time
+		want []string
+	}{
+		// Spring forward, 02:00 EST becomes 03:00 EDT
+		{"CRON_TZ=America/New_York 30 2 * * *", time.Date(2021, 3, 13, 12, 0, 0, 0, ny), []string{"03-14 03:00 EDT", "03-15 02:30 EDT"}},
+		{"TZ=America/New_York */30 * * * *", time.Date(2021, 3, 14, 1, 0, 0, 0, ny), []string{"03-14 01:30 EST", "03-14 03:00 EDT", "03-14 03:30 EDT"}},
+		// Fall back, 02:00 EDT becomes 01:00 EST
+		{"CRON_TZ=America/New_York 30 1 * * *", time.Date(2021, 11, 6, 12, 0, 0, 0, ny), []string{"11-07 01:30 EDT", "11-08 01:30 EST"}},
+		{"CRON_TZ=America/New_York */30 * * * *", time.Date(2021, 11, 7, 1, 0, 0, 0, ny), []string{"11-07 01:30 EDT", "11-07 02:00 EST", "11-07 02:30 EST"}},
+		// Starting during the repeated hour, after the first occurrence
+		{"CRON_TZ=America/New_York 30 1 * * *", time.Date(2021, 11, 7, 6, 10, 0, 0, time.UTC), []string{"11-08 01:30 EST"}},
+		// Without a time zone, the one of the time is used
+		{"30 2 * * *", time.Date(2021, 3, 13, 12, 0, 0, 0, ny), []string{"03-14 03:00 EDT", "03-15 02:30 EDT"}},
+		{"0 9 * * *", testTime, []string{"11-01 09:00 UTC"}},
+	}
+	for _, tt := range tests {
+		got := runs(tt.expr, tt.from, len(tt.want))
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("%q from %s runs at %v, want %v", tt.expr, tt.from, got, tt.want)
+		}
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	c, err := NewCronInLocation("0 9 * * *", berlin)
+	if err != nil {
+		t.Fatalf("NewCronInLocation Returned Error %s", err.Error())
+	}
+	if c.Spec() != "CRON_TZ=Europe/Berlin 0 9 * * *" || c.Location() != berlin {
+		t.Errorf("NewCronInLocation Spec %q, Location %v", c.Spec(), c.Location())
+	}
+	if next, _ := c.NextAfter(testTime); !next.Equal(time.Date(2021, 11, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("0 9 * * * in Europe/Berlin runs at %s", next.UTC())
+	}
+	if _, err := NewCronInLocation("CRON_TZ=UTC 0 9 * * *", berlin); err == nil {
+		t.Errorf("NewCronInLocation accepted a expression with a time zone")
+	}
+	for _, expr := range []string{"CRON_TZ=Nowhere/Invalid 0 9 * * *", "CRON_TZ=UTC"} {
+		if _, err := NewCron(expr); err == nil {
+			t.Errorf("NewCron(%q) did not return an error", expr)
+		}
+	}
+}
+
+func TestTimerRepeating(t *testing.T) {
+	fc := clock.NewFake(testTime)
+	timer, err := ParseRepeating("R3/2021-11-01T00:00:00Z/PT1H")
+	if err != nil {
+		t.Fatalf("ParseRepeating Returned Error %s", err.Error())
+	}
+	timer.SetClock(fc)
+	for i := 0; i < 3; i++ {
+		next, done := timer.Next()
+		if done || !next.Equal(testTime.Add(time.Duration(i)*time.Hour)) {
+			t.Errorf("Run %d at %s, %t", i, next, done)
+		}
+		fc.Set(next)
+	}
+	if _, done := timer.Next(); !done {
+		t.Errorf("Timer did not finish after 3 runs")
+	}
+
+	// Calendar periods follow the calendar, and a late start skips the runs already passed
+	monthly, _ := ParseRepeating("R/2021-01-31T03:00:00Z/P1M")
+	monthly.SetClock(clock.NewFake(testTime))
+	if next, _ := monthly.Next(); !next.Equal(time.Date(2021, 12, 1, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("P1M from 2021-01-31 next runs at %s", next)
+	}
+	if next, done := monthly.NextAfter(time.Date(2031, 6, 15, 0, 0, 0, 0, time.UTC)); done || !next.Equal(time.Date(2031, 7, 1, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("P1M from 2021-01-31 runs after 2031-06-15 at %s", next)
+	}
+	if next, done := timer.NextAfter(testTime.Add(1 * time.Hour)); done || !next.Equal(testTime.Add(2*time.Hour)) {
+		t.Errorf("NextAfter Returned %s, %t", next, done)
+	}
+	if _, done := timer.NextAfter(testTime.Add(2 * time.Hour)); !done {
+		t.Errorf("NextAfter the last run was not done")
+	}
+
+	for _, spec := range []string{"R5/2021-11-01T00:00:00Z/PT0S", "R5/2021-11-01T00:00:00Z", "Rx/2021-11-01T00:00:00Z/PT1H", "R5/2021-11-01/PT1H", "R5/2021-11-01T00:00:00Z/P1.5D", "R5/2021-11-01T00:00:00Z/PT", "R5/2021-11-01T00:00:00Z/P1H"} {
+		if _, err := ParseRepeating(spec); err == nil {
+			t.Errorf("ParseRepeating(%q) did not return an error", spec)
+		}
+	}
+}
+
+func TestParseTimer(t *testing.T) {
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"0 3 * * *", testTime.Add(3 * time.Hour)},
+		{"30 */5 * * * * *", testTime.Add(30 * time.Second)},
+		{"0 3 * * * 2030", time.Date(2030, 1, 1, 3, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=Europe/Berlin 0 3 * * *", testTime.Add(2 * time.Hour)},
+		{"@hourly", testTime.Add(1 * time.Hour)},
+		{"@daily", testTime.Add(24 * time.Hour)},
+		{"@every 90s", testTime.Add(90 * time.Second)},
+		{"@after 10s", testTime.Add(10 * time.Second)},
+		{"@at 2021-11-01T03:00:00Z", testTime.Add(3 * time.Hour)},
+		{"R5/2021-11-01T01:00:00Z/PT1H", testTime.Add(1 * time.Hour)},
+		{"R/2021-10-01T00:00:00+02:00/P1DT12H", time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)},
+		{"R2/2021-11-01T01:00:00Z/2021-11-01T01:30:00Z", testTime.Add(1 * time.Hour)},
+	}
+	for _, tt := range tests {
+		timer, err := ParseTimer(tt.spec)
+		if err != nil {
+			t.Errorf("ParseTimer(%q) Returned Error %s", tt.spec, err.Error())
+			continue
+		}
+		timer.(ClockSetter).SetClock(clock.NewFake(testTime))
+		if next, done := timer.Next(); done || !next.Equal(tt.next) {
+			t.Errorf("ParseTimer(%q) next runs at %s, want %s", tt.spec, next, tt.next)
+		}
+		if spec := timer.(TimerSpec).Spec(); spec != tt.spec {
+			if again, err := ParseTimer(spec); err != nil || timerSpec(again) != spec {
+				t.Errorf("ParseTimer(%q) has Spec %q, which does not parse back", tt.spec, spec)
+			}
+		}
+	}
+	for _, spec := range []string{"", "@every", "@every 0s", "@after -1s", "@at tomorrow", "* * *", "R5/PT1H"} {
+		if timer, err := ParseTimer(spec); err == nil || timer != nil {
+			t.Errorf("ParseTimer(%q) returned %v, %v", spec, timer, err)
+		}
+	}
+}